@@ -0,0 +1,190 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/mattn/go-isatty"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// forwardedSignals are relayed from howtfdoi to a running -x command.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// killGracePeriod is how long a timed-out command gets after SIGTERM
+// before its process group is sent SIGKILL.
+const killGracePeriod = 2 * time.Second
+
+// useProcessGroup runs cmd in its own process group so that it, and any
+// pipeline or background jobs it starts, can be signalled as a unit. When
+// stdin is a terminal the group becomes the terminal's foreground group, so
+// Ctrl-C goes to the command rather than to howtfdoi. The returned function
+// must be called after the command exits to take the terminal back.
+func useProcessGroup(cmd *exec.Cmd) (restore func()) {
+	fd := int(os.Stdin.Fd())
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		return func() {}
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: fd}
+	return func() {
+		// A background process changing the foreground group gets SIGTTOU
+		// unless it is ignored; the child has already exited, so ignoring
+		// it briefly can't leak into the command's environment.
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		_ = unix.IoctlSetPointerInt(fd, unix.TIOCSPGRP, unix.Getpgrp())
+	}
+}
+
+// signalProcessGroup sends sig to every process in cmd's process group.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+	if s, ok := sig.(syscall.Signal); ok {
+		_ = syscall.Kill(-cmd.Process.Pid, s)
+	}
+}
+
+// killProcessGroup asks cmd's process group to terminate, escalating to
+// SIGKILL if it is still alive after killGracePeriod.
+func killProcessGroup(cmd *exec.Cmd) {
+	signalProcessGroup(cmd, syscall.SIGTERM)
+	time.AfterFunc(killGracePeriod, func() {
+		signalProcessGroup(cmd, syscall.SIGKILL)
+	})
+}
+
+// interruptedBySignal reports whether err means the command was ended by
+// SIGINT or SIGTERM rather than failing on its own.
+func interruptedBySignal(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && (status.Signal() == syscall.SIGINT || status.Signal() == syscall.SIGTERM)
+}
+
+// ptyDrainTimeout bounds how long startPTY's finish waits for the last of
+// the command's output once it has exited.
+const ptyDrainTimeout = 500 * time.Millisecond
+
+// startPTY starts cmd on a new pseudo-terminal and relays howtfdoi's stdin
+// and stdout through it. The command gets its own session, so its process
+// group can still be signalled with signalProcessGroup. When stdin is a
+// terminal it is switched to raw mode (keys such as Ctrl-C then reach the
+// command through the pty) and window size changes are passed along.
+func startPTY(cmd *exec.Cmd) (finish func(), err error) {
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreMode := func() {}
+	resized := make(chan os.Signal, 1)
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		_ = pty.InheritSize(os.Stdin, ptmx)
+		signal.Notify(resized, syscall.SIGWINCH)
+		go func() {
+			for range resized {
+				_ = pty.InheritSize(os.Stdin, ptmx)
+			}
+		}()
+		if state, err := term.MakeRaw(fd); err == nil {
+			restoreMode = func() { _ = term.Restore(fd, state) }
+		}
+	}
+
+	input, stopInput := cancelableStdin()
+	if input != nil {
+		go func() { _, _ = io.Copy(ptmx, input) }()
+	}
+	output := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(os.Stdout, ptmx)
+		close(output)
+	}()
+
+	return func() {
+		// Reads fail once the command has exited and closed the terminal;
+		// don't hang if a background job it started is still holding it
+		select {
+		case <-output:
+		case <-time.After(ptyDrainTimeout):
+		}
+		signal.Stop(resized)
+		close(resized)
+		stopInput()
+		restoreMode()
+		_ = ptmx.Close()
+	}, nil
+}
+
+// cancelableStdin returns a reader over stdin whose pending Read can be
+// unblocked by calling stop, so relaying keystrokes to a finished command
+// doesn't swallow the next line meant for howtfdoi. It reads from a
+// non-blocking duplicate of the descriptor; stop puts stdin back into
+// blocking mode. The reader is nil if stdin can't be duplicated.
+func cancelableStdin() (r io.Reader, stop func()) {
+	fd := int(os.Stdin.Fd())
+	dup, err := unix.Dup(fd)
+	if err != nil {
+		return nil, func() {}
+	}
+	if err := unix.SetNonblock(dup, true); err != nil {
+		unix.Close(dup)
+		return nil, func() {}
+	}
+	f := os.NewFile(uintptr(dup), "stdin")
+	return f, func() {
+		_ = f.SetReadDeadline(time.Now())
+		_ = f.Close()
+		// The duplicate shares its file status flags with stdin
+		_ = unix.SetNonblock(fd, false)
+	}
+}
+
+// readKeyWithin waits up to timeout for a key on the terminal on stdin,
+// reading it without waiting for Enter. pressed is false on timeout.
+func readKeyWithin(timeout time.Duration) (key byte, pressed bool, err error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, false, err
+	}
+	defer term.Restore(fd, state)
+
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+	if errors.Is(err, unix.EINTR) {
+		return 0, false, nil
+	}
+	if err != nil || n == 0 {
+		return 0, false, err
+	}
+	// Read the whole key, so the rest of an escape sequence (an arrow
+	// key) isn't left for whatever reads stdin next
+	var buf [16]byte
+	if _, err := unix.Read(fd, buf[:]); err != nil {
+		return 0, false, err
+	}
+	return buf[0], true, nil
+}
+
+// detachProcess starts cmd in a session of its own, so it outlives
+// howtfdoi and isn't interrupted by Ctrl-C or a closing terminal.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// forwardedSignals are caught while a -x command runs. The console already
+// delivers Ctrl-C to every attached process, so catching it is enough to
+// keep howtfdoi alive while the command handles it.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// statusControlCExit is the exit code of a process ended by Ctrl-C.
+const statusControlCExit = 0xC000013A
+
+// useProcessGroup is a no-op on Windows: the command shares howtfdoi's
+// console, which already routes Ctrl-C to it.
+func useProcessGroup(cmd *exec.Cmd) (restore func()) {
+	return func() {}
+}
+
+// signalProcessGroup is a no-op on Windows; see forwardedSignals.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {}
+
+// killProcessGroup terminates cmd and its child processes.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// interruptedBySignal reports whether err means the command was ended by Ctrl-C.
+func interruptedBySignal(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && uint32(exitErr.ExitCode()) == statusControlCExit
+}
+
+// startPTY is not available on Windows; newExecutor rejects the pty
+// executor there before this can be reached.
+func startPTY(cmd *exec.Cmd) (finish func(), err error) {
+	return nil, errors.New("the pty executor is not supported on Windows")
+}
+
+// readKeyWithin is not available on Windows, so the countdown
+// confirmation falls back to asking.
+func readKeyWithin(timeout time.Duration) (key byte, pressed bool, err error) {
+	return 0, false, errors.New("reading single keys is not supported on Windows")
+}
+
+// detachProcess starts cmd without a console, so it outlives howtfdoi
+// and isn't interrupted by Ctrl-C.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS}
+}
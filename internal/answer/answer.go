@@ -0,0 +1,122 @@
+// Package answer defines the shape of the answers howtfdoi asks models
+// for: the system prompt that requests a command followed by a short
+// explanation, and the parser that reads a reply back into a Response.
+package answer
+
+import "strings"
+
+// Response is a parsed reply.
+// Kind distinguishes a single-answer reply (one command + explanation) from
+// an examples-mode reply (one or more "# title" blocks). Command/Explanation
+// are only populated for single-answer responses; examples responses must be
+// rendered from FullText.
+type Response struct {
+	Kind         Kind
+	Command      string
+	Explanation  string
+	FullText     string
+	References   []string // man page sections or doc URLs, from trailing "Ref: " lines
+	FlagWarnings []string // flags not found in the local tool's --help/man output, bashisms with --portable, and programs that aren't installed
+	Question     string   // the model's clarifying question, for KindQuestion
+	Cached       bool     // answered from the response cache
+	Offline      string   // where an offline answer came from and why, e.g. "the tldr page for tar; no API key is set"
+}
+
+// Kind classifies a parsed reply.
+type Kind int
+
+const (
+	KindSingle   Kind = iota // single command + explanation
+	KindExamples             // one or more "# title" example blocks
+	KindQuestion             // a clarifying question instead of an answer
+	KindOffTopic             // not a CLI question: a scope note, or a plain answer in general mode
+)
+
+// Parse extracts the command and explanation from a model's reply.
+// The expected format is:
+//   - First non-empty line: the actual command
+//   - Remaining lines: explanation/context
+//
+// Examples-mode responses (one or more "# title" blocks) are flagged with
+// Kind=KindExamples and Command/Explanation are intentionally left empty
+// so downstream features (copy, execute, safety warnings) don't act on a title
+// line. Renderers must use FullText for examples output.
+func Parse(text string) *Response {
+	text, refs := extractReferences(StripMarkdown(text))
+	response := &Response{
+		Kind:       KindSingle,
+		FullText:   text,
+		References: refs,
+	}
+
+	if looksLikeExamples(text) {
+		response.Kind = KindExamples
+		return response
+	}
+
+	// Filter out empty lines first to simplify parsing
+	var nonEmptyLines []string
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			nonEmptyLines = append(nonEmptyLines, trimmed)
+		}
+	}
+
+	if len(nonEmptyLines) > 0 {
+		response.Command = nonEmptyLines[0]
+	}
+	if len(nonEmptyLines) > 1 {
+		response.Explanation = strings.Join(nonEmptyLines[1:], "\n")
+	}
+	return response
+}
+
+func looksLikeExamples(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "# ") {
+			return true
+		}
+	}
+	return false
+}
+
+// referencePrefix marks a reference line in a response.
+const referencePrefix = "Ref: "
+
+// extractReferences removes trailing-style "Ref: " lines from text and
+// returns the remaining text and the references found.
+func extractReferences(text string) (string, []string) {
+	var refs []string
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, referencePrefix) {
+			if ref := strings.TrimSpace(strings.TrimPrefix(trimmed, referencePrefix)); ref != "" {
+				refs = append(refs, ref)
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n"), refs
+}
+
+// StripMarkdown removes markdown code fences and inline backticks from text.
+// The AI occasionally returns backtick-fenced blocks despite being told not to.
+func StripMarkdown(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for _, line := range lines {
+		// Drop lines that are only a code fence (``` or ```bash etc.)
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		// Strip inline backtick wrapping from a whole line (e.g. `command`)
+		if strings.HasPrefix(trimmed, "`") && strings.HasSuffix(trimmed, "`") && len(trimmed) > 2 {
+			line = trimmed[1 : len(trimmed)-1]
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
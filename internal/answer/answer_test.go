@@ -0,0 +1,152 @@
+package answer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantCommand string
+		wantExplain string
+	}{
+		{
+			name:        "standard response",
+			input:       "ls -la\nLists all files including hidden ones",
+			wantCommand: "ls -la",
+			wantExplain: "Lists all files including hidden ones",
+		},
+		{
+			name:        "response with empty lines",
+			input:       "grep -r 'pattern' .\n\nSearches recursively for pattern",
+			wantCommand: "grep -r 'pattern' .",
+			wantExplain: "Searches recursively for pattern",
+		},
+		{
+			name:        "command only",
+			input:       "cd /home/user",
+			wantCommand: "cd /home/user",
+			wantExplain: "",
+		},
+		{
+			name:        "multiline explanation",
+			input:       "find . -type f -name '*.go'\nFinds all Go files\nRecursively searches directories",
+			wantCommand: "find . -type f -name '*.go'",
+			wantExplain: "Finds all Go files\nRecursively searches directories",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.input)
+			if got.Kind != KindSingle {
+				t.Errorf("Parse() Kind = %v, want KindSingle", got.Kind)
+			}
+			if got.Command != tt.wantCommand {
+				t.Errorf("Parse() command = %v, want %v", got.Command, tt.wantCommand)
+			}
+			if got.Explanation != tt.wantExplain {
+				t.Errorf("Parse() explanation = %v, want %v", got.Explanation, tt.wantExplain)
+			}
+		})
+	}
+}
+
+// Test Parse handles examples-mode output: one or more "# title" blocks.
+// Command/Explanation must be empty so copy/execute/safety don't act on a title.
+func TestParseExamples(t *testing.T) {
+	input := "# List running containers\n" +
+		"docker ps\n" +
+		"Shows running containers.\n\n" +
+		"# List all containers\n" +
+		"docker ps -a\n" +
+		"Includes stopped containers."
+
+	got := Parse(input)
+
+	if got.Kind != KindExamples {
+		t.Fatalf("Parse() Kind = %v, want KindExamples", got.Kind)
+	}
+	if got.Command != "" {
+		t.Errorf("Parse() Command should be empty for examples, got %q", got.Command)
+	}
+	if got.Explanation != "" {
+		t.Errorf("Parse() Explanation should be empty for examples, got %q", got.Explanation)
+	}
+	if !strings.Contains(got.FullText, "docker ps -a") {
+		t.Errorf("Parse() FullText missing example content")
+	}
+}
+
+// Test the parse-level invariant that examples-mode responses leave Command
+// empty. Downstream consumers (handleResponse, TUI render path) all gate
+// clipboard copy / execute / danger scanning on Command != "", so keeping it
+// empty is what prevents those side effects from acting on a "# title" line.
+//
+// Covers the single "# title" block case, which looksLikeExamples() defines
+// as sufficient to trigger examples-mode. The consumer-side gating is
+// verified by code review rather than a stubbed integration test.
+func TestParseExamplesLeavesCommandEmpty(t *testing.T) {
+	resp := Parse("# Title\ncmd\nExplanation")
+	if resp.Kind != KindExamples {
+		t.Fatalf("Parse() Kind = %v, want KindExamples for a single example block", resp.Kind)
+	}
+	if resp.Command != "" {
+		t.Fatalf("Parse() examples response must have empty Command, got %q", resp.Command)
+	}
+}
+
+// TestParseReferences verifies "Ref: " lines are pulled out of the
+// explanation into References for both response kinds.
+func TestParseReferences(t *testing.T) {
+	got := Parse("tar -czf archive.tar.gz dir/\nCreates a compressed tarball.\nRef: man tar(1)\nRef: https://www.gnu.org/software/tar/manual/")
+	if got.Command != "tar -czf archive.tar.gz dir/" {
+		t.Errorf("Command = %q", got.Command)
+	}
+	if got.Explanation != "Creates a compressed tarball." {
+		t.Errorf("Explanation = %q, want refs removed", got.Explanation)
+	}
+	want := []string{"man tar(1)", "https://www.gnu.org/software/tar/manual/"}
+	if strings.Join(got.References, "|") != strings.Join(want, "|") {
+		t.Errorf("References = %q, want %q", got.References, want)
+	}
+
+	examples := Parse("# List containers\ndocker ps\nShows running containers.\n\nRef: https://docs.docker.com/reference/cli/docker/container/ls/")
+	if examples.Kind != KindExamples {
+		t.Fatalf("Kind = %v, want KindExamples", examples.Kind)
+	}
+	if strings.Contains(examples.FullText, "Ref:") || len(examples.References) != 1 {
+		t.Errorf("examples refs not extracted: FullText=%q References=%q", examples.FullText, examples.References)
+	}
+}
+
+// Benchmark response parsing
+func BenchmarkParse(b *testing.B) {
+	response := "find . -type f -name '*.go' -exec grep -l 'pattern' {} \\;\nFinds all Go files containing 'pattern'\nThis searches recursively through directories"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Parse(response)
+	}
+}
+
+// Benchmark StripMarkdown function
+func BenchmarkStripMarkdown(b *testing.B) {
+	input := "```bash\nls -la\n```\nThis is a command with **bold** and `code`"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StripMarkdown(input)
+	}
+}
+
+func TestSystemPrompt(t *testing.T) {
+	prompt := SystemPrompt("windows", false)
+	if !strings.Contains(prompt, "PowerShell-native") || strings.Contains(prompt, "tar -czf") {
+		t.Errorf("Windows prompt should ask for PowerShell commands:\n%s", prompt)
+	}
+	if strings.Contains(SystemPrompt("linux", false), "PowerShell") {
+		t.Error("Linux prompt shouldn't mention PowerShell")
+	}
+}
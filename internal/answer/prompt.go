@@ -0,0 +1,84 @@
+package answer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SystemPrompt returns the base system prompt for platform: one command
+// and a brief explanation, or with examples, several titled examples.
+// Callers append further rules, such as ReferencesRule, as needed.
+func SystemPrompt(platform string, showExamples bool) string {
+	noMarkdownRule := "- Output in PLAIN TEXT ONLY — no markdown, no backticks, no code fences. Never wrap commands in backtick or triple-backtick blocks."
+
+	if showExamples {
+		return "You are a command-line expert assistant. Provide multiple practical examples for the requested command or tool.\n\n" +
+			"Rules:\n" +
+			noMarkdownRule + "\n" +
+			"- Show 3-5 different use cases\n" +
+			"- Each example: a short title line prefixed with '# ', then the command on the next line, then a brief explanation on the following line\n" +
+			"- Separate each example with a blank line\n" +
+			"- Focus on common, practical scenarios\n\n" +
+			"Example format:\n" +
+			"# Create a compressed tarball\n" +
+			"tar -czf archive.tar.gz directory/\n" +
+			"Creates a gzip-compressed archive of the directory.\n\n" +
+			"# Extract a compressed tarball\n" +
+			"tar -xzf archive.tar.gz\n" +
+			"Extracts the archive into the current directory.\n\n" +
+			"# List archive contents\n" +
+			"tar -tzf archive.tar.gz\n" +
+			"Lists files in the archive without extracting them."
+	}
+
+	focus := "- Focus on common Unix/Linux CLI tools\n\n" +
+		"Example format:\n" +
+		"tar -czf archive.tar.gz directory/\n" +
+		"(Creates a compressed tarball of the directory)"
+	if platform == "windows" {
+		focus = "- Focus on PowerShell-native commands (cmdlets) and tools that ship with Windows; " +
+			"don't assume Unix tools like grep, sed, or awk are installed\n\n" +
+			"Example format:\n" +
+			"Compress-Archive -Path directory -DestinationPath archive.zip\n" +
+			"(Creates a zip archive of the directory)"
+	}
+
+	return fmt.Sprintf(
+		"You are a command-line expert assistant for %s systems. Provide concise, accurate answers about CLI tools and commands.\n\n"+
+			"Rules:\n"+
+			noMarkdownRule+"\n"+
+			"- Give the command/answer directly and immediately\n"+
+			"- Be extremely concise - no unnecessary explanation unless the command is complex\n"+
+			"- Show the actual command first, then a brief one-line explanation if needed\n"+
+			"- Provide platform-specific commands when relevant (%s vs Linux vs Windows)\n"+
+			"%s",
+		platform, platform, focus,
+	)
+}
+
+// ReferencesRule asks the model to cite where a user can verify the answer.
+// Refs go last so the first-line-is-the-command contract is unaffected.
+const ReferencesRule = "References:\n" +
+	"- After the answer, add one or two lines starting with 'Ref: ' pointing to authoritative documentation\n" +
+	"- Use a man page section (e.g. 'Ref: man tar(1)') or an official documentation URL\n" +
+	"- Only cite pages you are confident exist; omit the Ref lines rather than guess"
+
+// ClarifyRule lets the model ask one question instead of guessing. Send it
+// only when someone is there to answer.
+const ClarifyRule = "Clarifying questions:\n" +
+	"- If the request leaves out something that changes the command and a guess could do the wrong thing (which files, where, how old, which host), " +
+	"reply with exactly one line instead of an answer: '" + ClarifyPrefix + "<one short question>'\n" +
+	"- Ask only when it matters; for clear requests, or when a safe default exists, answer directly"
+
+// ClarifyPrefix marks a clarifying question in a response.
+const ClarifyPrefix = "Clarify: "
+
+// ParseClarification returns the question if text is a clarifying question.
+func ParseClarification(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	question, ok := strings.CutPrefix(text, ClarifyPrefix)
+	if !ok || strings.Contains(question, "\n") || strings.TrimSpace(question) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(question), true
+}
@@ -0,0 +1,218 @@
+// Package calc answers questions that are really local computations, such
+// as unit conversions, timestamp and number base conversions, and when a
+// cron schedule runs next, without asking a model.
+package calc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Answer works out query when it's one of the computations this package
+// knows, returning the answer as plain text. ok is false for anything
+// else, which should be asked as usual. now is the time cron schedules
+// and timestamps are shown relative to, in its location.
+func Answer(query string, now time.Time) (answer string, ok bool) {
+	q := normalize(query)
+	for _, solve := range []func(string, time.Time) (string, bool){solveCron, solveTimestamp, solveBase, solveUnits} {
+		if answer, ok := solve(q, now); ok {
+			return answer, true
+		}
+	}
+	return "", false
+}
+
+// normalize lowercases query, collapses its spaces, and drops the
+// punctuation around it.
+func normalize(query string) string {
+	q := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	return strings.TrimRight(strings.TrimLeft(q, "`'\" "), "?.!`'\" ")
+}
+
+// A unit of time or data, measured in nanoseconds or bytes.
+type unit struct {
+	Kind   string // "time" or "data"
+	Name   string // singular, or the symbol for data units
+	Plural string
+	Size   float64
+	Binary bool // a power of 1024
+	Power  int  // of 1000 or 1024, for data units
+}
+
+// units maps the ways a unit is written to the unit. Data units are
+// bytes: lowercase mb is read as MB, not megabits.
+var units = map[string]unit{}
+
+func init() {
+	add := func(u unit, names ...string) {
+		for _, name := range names {
+			units[name] = u
+		}
+	}
+	timeUnit := func(name string, size time.Duration) unit {
+		return unit{Kind: "time", Name: name, Plural: name + "s", Size: float64(size)}
+	}
+	day := 24 * time.Hour
+	add(timeUnit("nanosecond", time.Nanosecond), "ns", "nanosecond", "nanoseconds")
+	add(timeUnit("microsecond", time.Microsecond), "us", "µs", "microsecond", "microseconds")
+	add(timeUnit("millisecond", time.Millisecond), "ms", "millisecond", "milliseconds", "millis")
+	add(timeUnit("second", time.Second), "s", "sec", "secs", "second", "seconds")
+	add(timeUnit("minute", time.Minute), "m", "min", "mins", "minute", "minutes")
+	add(timeUnit("hour", time.Hour), "h", "hr", "hrs", "hour", "hours")
+	add(timeUnit("day", day), "d", "day", "days")
+	add(timeUnit("week", 7*day), "w", "wk", "wks", "week", "weeks")
+	add(timeUnit("year", 365*day), "y", "yr", "yrs", "year", "years")
+
+	dataUnit := func(symbol string, power int, binary bool) unit {
+		base := 1000.0
+		if binary {
+			base = 1024
+		}
+		return unit{Kind: "data", Name: symbol, Plural: symbol, Size: math.Pow(base, float64(power)), Binary: binary, Power: power}
+	}
+	add(unit{Kind: "data", Name: "byte", Plural: "bytes", Size: 1}, "b", "byte", "bytes")
+	for i, prefix := range []string{"k", "m", "g", "t", "p"} {
+		long := []string{"kilo", "mega", "giga", "tera", "peta"}[i]
+		short := []string{"kibi", "mebi", "gibi", "tebi", "pebi"}[i]
+		symbol := strings.ToUpper(prefix)
+		if prefix == "k" {
+			symbol = "k"
+		}
+		add(dataUnit(symbol+"B", i+1, false), prefix+"b", long+"byte", long+"bytes")
+		add(dataUnit(strings.ToUpper(prefix)+"iB", i+1, true), prefix+"ib", short+"byte", short+"bytes")
+	}
+}
+
+var (
+	// how many seconds are in 3 weeks, how many MB is 5 GiB
+	howManyQuestion = regexp.MustCompile(`^how many (\S+) (?:are there in|are in|is there in|is in|in|is|are|make|makes) (.+)$`)
+	// convert 5GiB to MB, 90 minutes in hours
+	convertQuestion = regexp.MustCompile(`^(?:convert |what is |what's |whats )?(.+?) (?:to|in|into|as) (\S+)$`)
+	// 3 weeks, 5GiB, 1.5 tb, a day
+	quantity = regexp.MustCompile(`^(?:(a|an|one) )?(\d[\d,_]*(?:\.\d+)?|\.\d+)? ?([a-zµ]+)$`)
+)
+
+// solveUnits converts between units of time or of data.
+func solveUnits(q string, _ time.Time) (string, bool) {
+	var from, to string
+	if m := howManyQuestion.FindStringSubmatch(q); m != nil {
+		from, to = m[2], m[1]
+	} else if m := convertQuestion.FindStringSubmatch(q); m != nil {
+		from, to = m[1], m[2]
+	} else {
+		return "", false
+	}
+	n, fromUnit, ok := parseQuantity(from)
+	if !ok {
+		return "", false
+	}
+	toUnit, ok := units[to]
+	if !ok || toUnit.Kind != fromUnit.Kind {
+		return "", false
+	}
+	result := n * fromUnit.Size / toUnit.Size
+	answer := fmt.Sprintf("%s %s = %s %s", formatNumber(n), fromUnit.label(n), formatNumber(result), toUnit.label(result))
+	switch {
+	case fromUnit.Kind == "data" && fromUnit.Binary != toUnit.Binary && fromUnit.Size > 1 && toUnit.Size > 1:
+		answer += "\n" + fromUnit.inBytes() + ", " + toUnit.inBytes() + "."
+	case (fromUnit.Name == "year" || toUnit.Name == "year") && fromUnit.Name != "day" && toUnit.Name != "day":
+		answer += "\nA year is counted as 365 days."
+	}
+	return answer, true
+}
+
+// parseQuantity reads "3 weeks", "5GiB", or "a day".
+func parseQuantity(s string) (float64, unit, bool) {
+	m := quantity.FindStringSubmatch(s)
+	if m == nil || (m[1] == "") == (m[2] == "") {
+		return 0, unit{}, false
+	}
+	u, ok := units[m[3]]
+	if !ok {
+		return 0, unit{}, false
+	}
+	if m[1] != "" {
+		return 1, u, true
+	}
+	n, err := strconv.ParseFloat(strings.NewReplacer(",", "", "_", "").Replace(m[2]), 64)
+	return n, u, err == nil
+}
+
+// label names u for a quantity of n.
+func (u unit) label(n float64) string {
+	if n == 1 {
+		return u.Name
+	}
+	return u.Plural
+}
+
+// inBytes says how many bytes a data unit is, e.g. "1 GiB is 1024^3 bytes".
+func (u unit) inBytes() string {
+	if u.Binary {
+		return fmt.Sprintf("1 %s is 1024^%d bytes", u.Name, u.Power)
+	}
+	return fmt.Sprintf("1 %s is 1000^%d bytes", u.Name, u.Power)
+}
+
+// formatNumber writes n without an exponent, rounded to six decimal
+// places.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*1e6)/1e6, 'f', -1, 64)
+}
+
+var (
+	// convert 0xff to decimal, 255 in binary
+	baseQuestion = regexp.MustCompile(`^(?:convert |what is |what's |whats )?(0x[0-9a-f]+|0b[01]+|0o[0-7]+|\d+) (?:to|in|into|as) (hex|hexadecimal|decimal|dec|binary|bin|octal|oct)$`)
+	bases        = map[string]int{"hex": 16, "hexadecimal": 16, "decimal": 10, "dec": 10, "binary": 2, "bin": 2, "octal": 8, "oct": 8}
+	basePrefixes = map[int]string{16: "0x", 10: "", 2: "0b", 8: "0o"}
+)
+
+// solveBase converts an integer between hex, decimal, binary, and octal.
+func solveBase(q string, _ time.Time) (string, bool) {
+	m := baseQuestion.FindStringSubmatch(q)
+	if m == nil {
+		return "", false
+	}
+	n, err := strconv.ParseUint(m[1], 0, 64)
+	if err != nil {
+		return "", false
+	}
+	base := bases[m[2]]
+	return m[1] + " = " + basePrefixes[base] + strconv.FormatUint(n, base), true
+}
+
+var (
+	// convert 1700000000 to a date, what date is epoch 1700000000
+	timestampQuestion = regexp.MustCompile(`^(?:convert )?(?:the )?(?:unix |epoch )?(?:timestamp |time )?(\d{9,13}) (?:to|in|into|as) (?:a |the )?(?:date|time|datetime|utc|local time|human[- ]readable(?: date| time)?)$`)
+	timestampWhen     = regexp.MustCompile(`^(?:what|which) (?:date|time|day) is (?:the )?(?:unix |epoch )?(?:timestamp |time )?(\d{9,13})$`)
+)
+
+// solveTimestamp shows a Unix timestamp, in seconds or (with 13 digits)
+// milliseconds, as a date in UTC and in now's location.
+func solveTimestamp(q string, now time.Time) (string, bool) {
+	m := timestampQuestion.FindStringSubmatch(q)
+	if m == nil {
+		m = timestampWhen.FindStringSubmatch(q)
+	}
+	if m == nil {
+		return "", false
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	t, unit := time.Unix(n, 0), ""
+	if len(m[1]) == 13 {
+		t, unit = time.UnixMilli(n), " (milliseconds)"
+	}
+	const layout = "Mon 2006-01-02 15:04:05 MST"
+	answer := fmt.Sprintf("Unix time %s%s is %s", m[1], unit, t.UTC().Format(layout))
+	if local := t.In(now.Location()); local.Format("MST") != "UTC" {
+		answer += "\nThat's " + local.Format(layout) + " here."
+	}
+	return answer, true
+}
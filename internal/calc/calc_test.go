@@ -0,0 +1,67 @@
+package calc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnswer(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 7, 30, 0, time.UTC)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"How many seconds in 3 weeks?", "3 weeks = 1814400 seconds"},
+		{"how many minutes are in a day", "1 day = 1440 minutes"},
+		{"90 min in hours", "90 minutes = 1.5 hours"},
+		{"convert 5GiB to MB", "5 GiB = 5368.70912 MB\n1 GiB is 1024^3 bytes, 1 MB is 1000^2 bytes."},
+		{"how many MiB is 1,500 MB", "1500 MB = 1430.511475 MiB\n1 MB is 1000^2 bytes, 1 MiB is 1024^2 bytes."},
+		{"2 tb to gb", "2 TB = 2000 GB"},
+		{"how many seconds in a year", "1 year = 31536000 seconds\nA year is counted as 365 days."},
+		{"0xff to decimal", "0xff = 255"},
+		{"convert 493 to octal", "493 = 0o755"},
+		{"convert 1700000000 to a date", "Unix time 1700000000 is Tue 2023-11-14 22:13:20 UTC"},
+		{"what date is 1700000000123", "Unix time 1700000000123 (milliseconds) is Tue 2023-11-14 22:13:20 UTC"},
+		{"crontab next run */20 * * * *", "Next runs of */20 * * * *:\n  Fri 2026-10-16 14:20 UTC (in 13m)\n  Fri 2026-10-16 14:40 UTC\n  Fri 2026-10-16 15:00 UTC"},
+		{"when does cron `30 4 1,15 * fri` run next", "Next runs of 30 4 1,15 * fri:\n  Fri 2026-10-23 04:30 UTC (in 6d 14h)\n  Fri 2026-10-30 04:30 UTC\n  Sun 2026-11-01 04:30 UTC"},
+		{"next run of cron @monthly", "Next runs of @monthly:\n  Sun 2026-11-01 00:00 UTC (in 15d 9h)\n  Tue 2026-12-01 00:00 UTC\n  Fri 2027-01-01 00:00 UTC"},
+		{"when does cron 0 0 30 feb * run next", "0 0 30 feb * never runs: no date matches it."},
+	}
+	for _, tt := range tests {
+		got, ok := Answer(tt.query, now)
+		if !ok || got != tt.want {
+			t.Errorf("Answer(%q) = %q, %v; want %q", tt.query, got, ok, tt.want)
+		}
+	}
+
+	// Questions for the model, even those that look like conversions
+	for _, query := range []string{
+		"convert png to jpg",
+		"how many lines in a file",
+		"change the timeout to 30s",
+		"convert 5 GiB to seconds",
+		"write a crontab entry for 0 3 * * *",
+		"when does cron run my script",
+		"how many files in 3 directories",
+	} {
+		if got, ok := Answer(query, now); ok {
+			t.Errorf("Answer(%q) = %q, want it asked", query, got)
+		}
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	// 02:30 doesn't exist on 2026-03-29, so the next run is the day after
+	s, ok := parseCron([]string{"30", "2", "*", "*", "*"})
+	if !ok {
+		t.Fatal("parseCron failed")
+	}
+	next, ok := s.next(time.Date(2026, 3, 29, 1, 0, 0, 0, berlin))
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin); !ok || !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+}
@@ -0,0 +1,218 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronRuns is how many upcoming runs a cron answer lists.
+const cronRuns = 3
+
+// cronQuestion finds questions about when a schedule runs, as opposed to
+// asking for a crontab line to be written.
+var cronQuestion = regexp.MustCompile(`\bcron(?:tab)?\b.*\b(?:next|when)\b|\b(?:next|when)\b.*\bcron(?:tab)?\b`)
+
+// cronMacros are the @ shorthands for common schedules.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField is one field of a schedule: its range and the names it
+// accepts in place of numbers.
+type cronField struct {
+	Min, Max int
+	Names    []string // Names[i] stands for Min+i
+}
+
+var (
+	cronMinute  = cronField{Min: 0, Max: 59}
+	cronHour    = cronField{Min: 0, Max: 23}
+	cronDay     = cronField{Min: 1, Max: 31}
+	cronMonth   = cronField{Min: 1, Max: 12, Names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronWeekday = cronField{Min: 0, Max: 7, Names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+)
+
+// cronSchedule is a parsed five-field schedule: the values each field
+// matches.
+type cronSchedule struct {
+	Minute, Hour, Day, Month, Weekday []bool
+	// Both Day and Weekday were restricted, so a day matches if either
+	// does, as in Vixie cron
+	EitherDay bool
+}
+
+// solveCron lists the next runs of the schedule a question names, e.g.
+// "when does cron 0 3 * * 1 run next".
+func solveCron(q string, now time.Time) (string, bool) {
+	if !cronQuestion.MatchString(q) {
+		return "", false
+	}
+	words := strings.Fields(strings.Trim(strings.NewReplacer("`", " ", "'", " ", "\"", " ").Replace(q), " "))
+	if slices.Contains(words, "@reboot") {
+		return "@reboot runs once, when cron starts at boot, not on a schedule.", true
+	}
+	var expr string
+	var schedule cronSchedule
+	for i := range words {
+		if spec, ok := cronMacros[words[i]]; ok {
+			expr, schedule = words[i], mustParseCron(spec)
+			break
+		}
+		if i+5 > len(words) {
+			continue
+		}
+		if s, ok := parseCron(words[i : i+5]); ok {
+			expr, schedule = strings.Join(words[i:i+5], " "), s
+			break
+		}
+	}
+	if expr == "" {
+		return "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Next runs of %s:", expr)
+	t := now
+	for range cronRuns {
+		next, ok := schedule.next(t)
+		if !ok {
+			if t == now {
+				return expr + " never runs: no date matches it.", true
+			}
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s", next.Format("Mon 2006-01-02 15:04 MST"))
+		if t == now {
+			fmt.Fprintf(&b, " (in %s)", roughDuration(next.Sub(now)))
+		}
+		t = next
+	}
+	return b.String(), true
+}
+
+// mustParseCron parses one of the cronMacros.
+func mustParseCron(spec string) cronSchedule {
+	s, ok := parseCron(strings.Fields(spec))
+	if !ok {
+		panic("calc: invalid cron macro " + spec)
+	}
+	return s
+}
+
+// parseCron parses the five fields of a schedule: minute, hour, day of
+// month, month, and day of week.
+func parseCron(fields []string) (cronSchedule, bool) {
+	var s cronSchedule
+	var ok [5]bool
+	s.Minute, ok[0] = cronMinute.parse(fields[0])
+	s.Hour, ok[1] = cronHour.parse(fields[1])
+	s.Day, ok[2] = cronDay.parse(fields[2])
+	s.Month, ok[3] = cronMonth.parse(fields[3])
+	s.Weekday, ok[4] = cronWeekday.parse(fields[4])
+	if slices.Contains(ok[:], false) {
+		return cronSchedule{}, false
+	}
+	s.Weekday[0] = s.Weekday[0] || s.Weekday[7]
+	s.EitherDay = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+	return s, true
+}
+
+// parse reads a field: *, a value, a range, a list, and any of those
+// with a /step.
+func (f cronField) parse(field string) ([]bool, bool) {
+	matches := make([]bool, f.Max+1)
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepText, stepped := strings.Cut(part, "/")
+		step := 1
+		if stepped {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			step = n
+		}
+		lo, hi := f.Min, f.Max
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var ok bool
+			if lo, ok = f.value(first); !ok {
+				return nil, false
+			}
+			hi = lo
+			if isRange {
+				if hi, ok = f.value(last); !ok || hi < lo {
+					return nil, false
+				}
+			} else if stepped {
+				hi = f.Max // 5/15 is 5-59/15
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			matches[v] = true
+		}
+	}
+	return matches, true
+}
+
+// value reads a number or name in the field's range.
+func (f cronField) value(s string) (int, bool) {
+	if i := slices.Index(f.Names, s); i >= 0 {
+		return f.Min + i, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= f.Min && n <= f.Max
+}
+
+// next returns the first time after t the schedule runs. ok is false
+// when it doesn't run in the next five years, as for February 30th.
+func (s cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.Month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.Hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.Minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dayMatches reports whether the schedule runs on t's day.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	day, weekday := s.Day[t.Day()], s.Weekday[t.Weekday()]
+	if s.EitherDay {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// roughDuration writes d in its two largest units, e.g. "2d 14h".
+func roughDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d.Hours())/24, int(d.Hours())%24, int(d.Minutes())%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
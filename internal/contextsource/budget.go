@@ -0,0 +1,160 @@
+package contextsource
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken is the rough characters-per-token ratio used for estimates.
+// Close enough across Claude/GPT tokenizers for budgeting purposes.
+const charsPerToken = 4
+
+// relevantLinePattern marks lines worth keeping from the middle of long
+// output even when everything around them is dropped.
+var relevantLinePattern = regexp.MustCompile(`(?i)\b(error|errors|fail|failed|failure|fatal|panic|exception|traceback|denied|not found|warning|undefined|segmentation)\b`)
+
+// Truncation describes what Fit dropped from one block.
+type Truncation struct {
+	Source         string
+	OriginalTokens int
+	KeptTokens     int
+	DroppedLines   int
+}
+
+// EstimateTokens returns a rough token count for text.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// Fit truncates blocks so their combined size stays within budget tokens.
+// Small blocks are kept whole and their unused share goes to the larger
+// ones. Returns the fitted blocks and a report per truncated block.
+func Fit(blocks []Block, budget int, query string) ([]Block, []Truncation) {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return len(blocks[order[a]].Content) < len(blocks[order[b]].Content)
+	})
+
+	fitted := make([]Block, len(blocks))
+	var reports []Truncation
+	remaining := budget
+	for n, i := range order {
+		share := remaining / (len(order) - n)
+		block := blocks[i]
+		original := EstimateTokens(block.Content)
+		if original > share {
+			var dropped int
+			block.Content, dropped = truncate(block.Content, share, query)
+			reports = append(reports, Truncation{
+				Source:         block.Source,
+				OriginalTokens: original,
+				KeptTokens:     EstimateTokens(block.Content),
+				DroppedLines:   dropped,
+			})
+		}
+		remaining -= EstimateTokens(block.Content)
+		fitted[i] = block
+	}
+	return fitted, reports
+}
+
+// truncate shrinks content to roughly budget tokens, keeping the head
+// and tail (where commands print banners and final errors) plus any lines
+// from the middle that look relevant — error markers or words from query.
+// Omitted runs are replaced with a marker. Returns the text and how many
+// lines were dropped.
+func truncate(content string, budget int, query string) (string, int) {
+	if EstimateTokens(content) <= budget {
+		return content, 0
+	}
+	maxChars := budget * charsPerToken
+
+	lines := strings.Split(content, "\n")
+	// Minified or binary-ish output: one enormous line. Clip lines so a
+	// single one can't eat the whole budget.
+	lineLimit := maxChars / 4
+	if lineLimit < 80 {
+		lineLimit = 80
+	}
+	for i, line := range lines {
+		if r := []rune(line); len(r) > lineLimit {
+			lines[i] = string(r[:lineLimit]) + " …[line truncated]"
+		}
+	}
+
+	var keywords []string
+	for _, w := range strings.Fields(strings.ToLower(query)) {
+		if len(w) >= 4 {
+			keywords = append(keywords, w)
+		}
+	}
+	relevant := func(line string) bool {
+		if relevantLinePattern.MatchString(line) {
+			return true
+		}
+		lower := strings.ToLower(line)
+		for _, k := range keywords {
+			if strings.Contains(lower, k) {
+				return true
+			}
+		}
+		return false
+	}
+
+	keep := make([]bool, len(lines))
+	used := 0
+	take := func(i, limit int) bool {
+		cost := len(lines[i]) + 1
+		if keep[i] || used+cost > limit {
+			return false
+		}
+		keep[i] = true
+		used += cost
+		return true
+	}
+
+	// 40% head, 40% tail, the rest for relevant lines from the middle
+	headLimit := maxChars * 2 / 5
+	for i := 0; i < len(lines); i++ {
+		if !take(i, headLimit) {
+			break
+		}
+	}
+	tailLimit := used + maxChars*2/5
+	for i := len(lines) - 1; i >= 0 && !keep[i]; i-- {
+		if !take(i, tailLimit) {
+			break
+		}
+	}
+	for i := range lines {
+		if !keep[i] && relevant(lines[i]) {
+			take(i, maxChars)
+		}
+	}
+
+	var out []string
+	dropped, run := 0, 0
+	flush := func() {
+		if run > 0 {
+			out = append(out, fmt.Sprintf("[... %d lines omitted ...]", run))
+			run = 0
+		}
+	}
+	for i, line := range lines {
+		if keep[i] {
+			flush()
+			out = append(out, line)
+			continue
+		}
+		dropped++
+		run++
+	}
+	flush()
+	return strings.Join(out, "\n"), dropped
+}
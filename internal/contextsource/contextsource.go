@@ -0,0 +1,114 @@
+// Package contextsource gathers the background attached to a query. Each
+// kind of context (platform, shell, git, installed tools, files, command
+// output, ...) is a Source that registers itself by name; sources are
+// turned on by Settings from the config file, and what each gathers is
+// fitted to its own token budget before it's added to the prompt.
+package contextsource
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Block is a piece of supplementary material (file contents, command
+// output) attached to a query.
+type Block struct {
+	Source  string // where it came from, e.g. "stdin" or a file path
+	Content string
+}
+
+// Settings is one source's entry under context_sources.
+type Settings struct {
+	Enabled    bool     `yaml:"enabled"`
+	Tokens     int      `yaml:"tokens,omitempty"`     // token budget for this source; 0 = its default
+	Paths      []string `yaml:"paths,omitempty"`      // files source: files to attach (~ is expanded)
+	Commands   []string `yaml:"commands,omitempty"`   // command source: commands whose output is attached
+	Production []string `yaml:"production,omitempty"` // kube source: patterns for contexts that look like production
+}
+
+// Source gathers one kind of background for a query. Gather returns
+// nothing when the source doesn't apply (e.g. git outside a repository).
+type Source struct {
+	Name   string
+	Tokens int // default token budget
+	Gather func(settings Settings, query string) []Block
+}
+
+// sources are the registered sources, in the order their blocks are
+// attached.
+var sources []Source
+
+// Register adds a source. Sources are attached in the order they were
+// registered; settings, budgets, and the --context flag find them by
+// name. It panics if the name is empty or already taken.
+func Register(source Source) {
+	if source.Name == "" || source.Gather == nil {
+		panic("contextsource: Register needs a name and a Gather func")
+	}
+	if slices.ContainsFunc(sources, func(s Source) bool { return s.Name == source.Name }) {
+		panic("contextsource: Register called twice for " + source.Name)
+	}
+	sources = append(sources, source)
+}
+
+// Names returns the names of all registered sources.
+func Names() []string {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Enable turns on the comma-separated sources in list, keeping any
+// per-source settings already in settings, which isn't modified.
+func Enable(settings map[string]Settings, list string) (map[string]Settings, error) {
+	out := maps.Clone(settings)
+	if out == nil {
+		out = map[string]Settings{}
+	}
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(Names(), name) {
+			return nil, fmt.Errorf("unknown context source '%s' (expected %s)", name, strings.Join(Names(), ", "))
+		}
+		s := out[name]
+		s.Enabled = true
+		out[name] = s
+	}
+	return out, nil
+}
+
+// Gathered is what one source attached to a query.
+type Gathered struct {
+	Source    string // the source's name
+	Blocks    []Block
+	Truncated []Truncation
+}
+
+// Gather runs every source enabled in settings, in registration order,
+// and fits each one's blocks to its own budget: Settings.Tokens, or the
+// source's default. Sources that gather nothing are left out.
+func Gather(settings map[string]Settings, query string) []Gathered {
+	var out []Gathered
+	for _, source := range sources {
+		s, ok := settings[source.Name]
+		if !ok || !s.Enabled {
+			continue
+		}
+		blocks := source.Gather(s, query)
+		if len(blocks) == 0 {
+			continue
+		}
+		budget := cmp.Or(max(s.Tokens, 0), source.Tokens)
+		fitted, truncated := Fit(blocks, budget, query)
+		out = append(out, Gathered{Source: source.Name, Blocks: fitted, Truncated: truncated})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package contextsource
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTruncate verifies long output keeps head, tail, and relevant
+// middle lines within budget, marking what was omitted.
+func TestTruncate(t *testing.T) {
+	var lines []string
+	lines = append(lines, "=== build started ===")
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("compiling package number %d of the project", i))
+	}
+	lines[250] = "main.go:42: undefined: frobnicate"
+	lines = append(lines, "make: *** [all] Error 1")
+	content := strings.Join(lines, "\n")
+
+	got, dropped := truncate(content, 400, "why did the build fail")
+
+	if EstimateTokens(got) > 450 {
+		t.Errorf("truncated to ~%d tokens, want about 400", EstimateTokens(got))
+	}
+	if dropped == 0 {
+		t.Error("expected some lines to be dropped")
+	}
+	for _, want := range []string{"=== build started ===", "make: *** [all] Error 1", "undefined: frobnicate", "lines omitted"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("truncated output missing %q", want)
+		}
+	}
+
+	short := "just one line"
+	if got, dropped := truncate(short, 400, ""); got != short || dropped != 0 {
+		t.Errorf("truncate() changed content under budget: %q, %d", got, dropped)
+	}
+}
+
+// TestFit verifies small blocks stay whole and the total stays
+// within budget.
+func TestFit(t *testing.T) {
+	small := Block{Source: "small", Content: "exit status 1"}
+	big := Block{Source: "big", Content: strings.Repeat("log line with some words\n", 2000)}
+
+	fitted, reports := Fit([]Block{big, small}, 1000, "")
+
+	if fitted[1].Content != small.Content {
+		t.Errorf("small block was modified: %q", fitted[1].Content)
+	}
+	total := EstimateTokens(fitted[0].Content) + EstimateTokens(fitted[1].Content)
+	if total > 1100 {
+		t.Errorf("fitted blocks total ~%d tokens, want about 1000", total)
+	}
+	if len(reports) != 1 || reports[0].Source != "big" {
+		t.Errorf("reports = %+v, want one report for the big block", reports)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	defer func(saved []Source) { sources = saved }(sources)
+	sources = nil
+	Register(Source{Name: "short", Tokens: 100, Gather: func(_ Settings, query string) []Block {
+		return []Block{{Source: "short", Content: "asked: " + query}}
+	}})
+	Register(Source{Name: "empty", Tokens: 100, Gather: func(Settings, string) []Block { return nil }})
+	Register(Source{Name: "long", Tokens: 50, Gather: func(Settings, string) []Block {
+		return []Block{{Source: "long", Content: strings.Repeat("line of output\n", 200)}}
+	}})
+	if got := Names(); !slices.Equal(got, []string{"short", "empty", "long"}) {
+		t.Errorf("Names() = %v, want registration order", got)
+	}
+
+	settings := map[string]Settings{"long": {Tokens: 20}}
+	enabled, err := Enable(settings, "long, Empty,short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["long"].Enabled {
+		t.Error("Enable modified its argument")
+	}
+	gathered := Gather(enabled, "why")
+	if len(gathered) != 2 || gathered[0].Source != "short" || gathered[1].Source != "long" {
+		t.Fatalf("Gather() = %+v, want short then long", gathered)
+	}
+	if got := gathered[0].Blocks[0].Content; got != "asked: why" || len(gathered[0].Truncated) != 0 {
+		t.Errorf("short source gathered %q, truncated %v", got, gathered[0].Truncated)
+	}
+	if got := EstimateTokens(gathered[1].Blocks[0].Content); got > 30 || len(gathered[1].Truncated) != 1 {
+		t.Errorf("long source kept ~%d tokens, want it fitted to its configured 20", got)
+	}
+	if got := Gather(map[string]Settings{"short": {}}, "why"); len(got) != 0 {
+		t.Errorf("Gather() ran a disabled source: %+v", got)
+	}
+
+	if _, err := Enable(nil, "short,clipboard"); err == nil || !strings.Contains(err.Error(), "expected short, empty, long") {
+		t.Errorf("Enable(unknown) error = %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("registering a name twice didn't panic")
+			}
+		}()
+		Register(Source{Name: "short", Gather: func(Settings, string) []Block { return nil }})
+	}()
+}
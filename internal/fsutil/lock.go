@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout bounds how long Lock waits for another process; it
+	// matches the SQLite history's busy timeout
+	lockTimeout = 5 * time.Second
+
+	lockPollInterval = 20 * time.Millisecond
+)
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock takes an exclusive advisory lock on path + ".lock" and returns the
+// function that releases it. State files shared by every howtfdoi process
+// (splits, scripts, shells) are read, changed, and written back under it,
+// so concurrent updates are serialized instead of the last writer
+// silently dropping the others' changes. It waits up to lockTimeout for
+// another process to finish.
+func Lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("%s is still being updated by another howtfdoi process after %v", path, lockTimeout)
+			}
+			return nil, fmt.Errorf("could not lock %s: %w", path, err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
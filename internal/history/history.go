@@ -0,0 +1,714 @@
+// Package history records queries and their responses. Store is the
+// storage interface; the SQLite, plain-text file, and in-memory backends
+// implement it.
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neckbeardprince/howtfdoi/internal/fsutil"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// Entry is one recorded query and its response.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Query    string    `json:"query"`
+	Response string    `json:"response"`
+	// Project identifies the git repository the query was asked in,
+	// e.g. "github.com/owner/repo"; empty outside a repository.
+	Project string `json:"project,omitempty"`
+
+	// The parsed answer and where it came from. The plain-text file keeps
+	// only the fields above; the other backends keep these too.
+	Command     string `json:"command,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Platform    string `json:"platform,omitempty"` // e.g. "linux"
+	Executed    bool   `json:"executed,omitempty"` // the command was run (with -x)
+	// Environment describes where it was last run: OS, shell, working
+	// directory, and tool versions.
+	Environment string `json:"environment,omitempty"`
+	// Recording is the path of a terminal recording of that run, if one
+	// was made.
+	Recording string `json:"recording,omitempty"`
+}
+
+// Store is the storage backend for query history. SQLite is the
+// default; other backends can be swapped in via the history_backend
+// config key without touching callers.
+type Store interface {
+	// Save appends an entry. Callers apply the privacy filter first.
+	Save(entry Entry) error
+	// Search returns up to limit entries whose query or response contains
+	// term (case-insensitive), newest first. An empty term matches
+	// everything; limit <= 0 means no limit.
+	Search(term string, limit int) ([]Entry, error)
+	// SearchProject is Search restricted to entries tagged with project.
+	// An empty project matches every entry, like Search.
+	SearchProject(project, term string, limit int) ([]Entry, error)
+	// Prune deletes entries older than cutoff and returns how many it removed.
+	Prune(cutoff time.Time) (int, error)
+	// Keep deletes all but the newest n entries and returns how many it
+	// removed.
+	Keep(n int) (int, error)
+	// MarkExecuted records that the command of a saved entry, identified
+	// by its time and query, was run in entry.Environment (and recorded to
+	// entry.Recording). The plain-text file ignores it.
+	MarkExecuted(entry Entry) error
+	// Import saves the entries that aren't already stored (same time and
+	// query), as when migrating or syncing, and returns how many it added.
+	Import(entries []Entry) (int, error)
+	Close() error
+}
+
+// Compacter is implemented by stores whose files keep the data of deleted
+// entries until they are compacted.
+type Compacter interface {
+	Compact() error
+}
+
+// Backend names accepted by Open (the history_backend config key).
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+	BackendMemory = "memory"
+)
+
+// File names Open uses inside the data directory.
+const (
+	FileName   = "history.log"
+	DBFileName = "history.db"
+)
+
+// Backends lists the valid backend names.
+var Backends = []string{BackendFile, BackendSQLite, BackendMemory}
+
+// TimeLayout is the timestamp format of the plain-text history file.
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Open creates the backend named by backend for dataDir. The SQLite
+// backend first imports a plain-text history file left in dataDir by
+// earlier versions (see MigrateFile).
+func Open(backend, dataDir string) (Store, error) {
+	switch strings.ToLower(backend) {
+	case BackendFile:
+		return NewFileStore(filepath.Join(dataDir, FileName)), nil
+	case "", BackendSQLite:
+		store, err := OpenSQLite(filepath.Join(dataDir, DBFileName))
+		if err != nil {
+			return nil, err
+		}
+		if err := MigrateFile(store, filepath.Join(dataDir, FileName)); err != nil {
+			store.Close()
+			return nil, err
+		}
+		return store, nil
+	case BackendMemory:
+		return &MemoryStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown history backend %q (expected %s)", backend, strings.Join(Backends, ", "))
+	}
+}
+
+// Retention limits how much history is kept. Zero fields mean no limit.
+type Retention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+	MaxBytes   int64 // measured as the plain-text file format, whatever the backend
+}
+
+// Enabled reports whether any limit is set.
+func (r Retention) Enabled() bool {
+	return r.MaxEntries > 0 || r.MaxAge > 0 || r.MaxBytes > 0
+}
+
+// Apply deletes the entries of store that are over r's limits, oldest
+// first, and returns how many it removed.
+func (r Retention) Apply(store Store, now time.Time) (int, error) {
+	removed := 0
+	if r.MaxAge > 0 {
+		n, err := store.Prune(now.Add(-r.MaxAge))
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	if r.MaxEntries <= 0 && r.MaxBytes <= 0 {
+		return removed, nil
+	}
+	keep := r.MaxEntries
+	if r.MaxBytes > 0 {
+		entries, err := store.Search("", 0)
+		if err != nil {
+			return removed, err
+		}
+		fit := len(entries)
+		var size int64
+		for i, e := range entries { // newest first
+			if size += int64(len(formatEntry(e))); size > r.MaxBytes {
+				fit = i
+				break
+			}
+		}
+		if keep <= 0 || fit < keep {
+			keep = fit
+		}
+	}
+	n, err := store.Keep(keep)
+	return removed + n, err
+}
+
+// MigratedSuffix is appended to a plain-text history file's name once
+// MigrateFile has imported it.
+const MigratedSuffix = ".migrated"
+
+// MigrateFile imports the plain-text history file at path into store and
+// renames it with MigratedSuffix, so it is imported only once. A missing
+// file is not an error.
+func MigrateFile(store Store, path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	// Another process starting at the same time may migrate it first
+	unlock, err := fsutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	entries, err := NewFileStore(path).load()
+	if err != nil {
+		return fmt.Errorf("could not read %s to migrate it: %w", path, err)
+	}
+	if _, err := store.Import(entries); err != nil {
+		return fmt.Errorf("could not migrate %s: %w", path, err)
+	}
+	return os.Rename(path, path+MigratedSuffix)
+}
+
+// sameEntry reports whether a and b record the same query: Import's
+// notion of a duplicate. Times are compared to the second, as the
+// plain-text format keeps them, so an entry that went through it still
+// matches its original.
+func sameEntry(a, b Entry) bool {
+	return a.Time.Unix() == b.Time.Unix() && a.Query == b.Query
+}
+
+// matchesHistory reports whether e belongs to project and contains the
+// lowercased term.
+func matchesHistory(e Entry, project, term string) bool {
+	if project != "" && e.Project != project {
+		return false
+	}
+	return term == "" || strings.Contains(strings.ToLower(e.Query), term) || strings.Contains(strings.ToLower(e.Response), term)
+}
+
+// searchEntries filters entries (oldest first) and returns matches newest first.
+func searchEntries(entries []Entry, project, term string, limit int) []Entry {
+	term = strings.ToLower(term)
+	var found []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if matchesHistory(entries[i], project, term) {
+			found = append(found, entries[i])
+			if limit > 0 && len(found) == limit {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// FileStore keeps history in the human-readable log format:
+// "[YYYY-MM-DD HH:MM:SS] query\nresponse\n---\n", with the project
+// inside the brackets after the timestamp when there is one.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a store for the history file at path, which is
+// created on the first Save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save appends entry. Like the rewrites below, it holds the file's lock,
+// so an entry saved by another process while one rewrites the file isn't
+// lost.
+func (s *FileStore) Save(entry Entry) error {
+	unlock, err := fsutil.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Queries can contain sensitive context; tighten files created
+	// world-readable by older versions (OpenFile only sets the mode on create)
+	// (best effort: the entry is still written if this fails)
+	_ = f.Chmod(0600)
+
+	_, err = f.WriteString(formatEntry(entry))
+	return err
+}
+
+func (s *FileStore) load() ([]Entry, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseEntries(string(data)), nil
+}
+
+func (s *FileStore) Search(term string, limit int) ([]Entry, error) {
+	return s.SearchProject("", term, limit)
+}
+
+func (s *FileStore) SearchProject(project, term string, limit int) ([]Entry, error) {
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return searchEntries(entries, project, term, limit), nil
+}
+
+func (s *FileStore) Prune(cutoff time.Time) (int, error) {
+	unlock, err := fsutil.Lock(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Work on raw chunks so entries that don't parse are kept verbatim
+	var kept strings.Builder
+	removed := 0
+	for _, chunk := range SplitEntries(string(data)) {
+		if e, ok := parseEntry(chunk); ok && e.Time.Before(cutoff) {
+			removed++
+			continue
+		}
+		kept.WriteString(chunk)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, fsutil.WriteFileAtomic(s.path, []byte(kept.String()), 0600)
+}
+
+func (s *FileStore) Keep(n int) (int, error) {
+	unlock, err := fsutil.Lock(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	chunks := SplitEntries(string(data))
+	if len(chunks) <= n {
+		return 0, nil
+	}
+	removed := len(chunks) - n
+	return removed, fsutil.WriteFileAtomic(s.path, []byte(strings.Join(chunks[removed:], "")), 0600)
+}
+
+func (s *FileStore) MarkExecuted(Entry) error { return nil }
+
+// Import merges entries into the file, which stays in time order.
+// Entries already in it are kept verbatim, even ones that don't parse.
+func (s *FileStore) Import(entries []Entry) (int, error) {
+	unlock, err := fsutil.Lock(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	chunks := SplitEntries(string(data))
+	var existing []Entry
+	for _, chunk := range chunks {
+		if e, ok := parseEntry(chunk); ok {
+			existing = append(existing, e)
+		}
+	}
+	added := 0
+	for _, e := range entries {
+		if slices.ContainsFunc(existing, func(x Entry) bool { return sameEntry(x, e) }) {
+			continue
+		}
+		existing = append(existing, e)
+		chunks = append(chunks, formatEntry(e))
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	// "[2006-01-02 15:04:05]" prefixes sort lexically in time order
+	slices.SortStableFunc(chunks, strings.Compare)
+	return added, fsutil.WriteFileAtomic(s.path, []byte(strings.Join(chunks, "")), 0600)
+}
+
+func (s *FileStore) Close() error { return nil }
+
+// FormatEntries renders entries (oldest first) in the plain-text history
+// file format, e.g. for sync.
+func FormatEntries(entries []Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(formatEntry(e))
+	}
+	return b.String()
+}
+
+// ParseEntries parses text in the plain-text history file format,
+// skipping entries that don't parse.
+func ParseEntries(text string) []Entry {
+	var entries []Entry
+	for _, chunk := range SplitEntries(text) {
+		if e, ok := parseEntry(chunk); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// formatEntry renders e in the history file format.
+func formatEntry(e Entry) string {
+	stamp := e.Time.Format(TimeLayout)
+	if e.Project != "" {
+		stamp += " " + e.Project
+	}
+	return fmt.Sprintf("[%s] %s\n%s\n---\n", stamp, e.Query, e.Response)
+}
+
+// parseEntry parses one chunk from SplitEntries. Timestamps
+// are local time, matching how they were written.
+func parseEntry(chunk string) (Entry, bool) {
+	chunk = strings.TrimSuffix(strings.TrimLeft(chunk, "\n"), "\n---\n")
+	header, response, _ := strings.Cut(chunk, "\n")
+	if !strings.HasPrefix(header, "[") {
+		return Entry{}, false
+	}
+	stamp, query, ok := strings.Cut(header[1:], "] ")
+	if !ok {
+		return Entry{}, false
+	}
+	var project string
+	if len(stamp) > len(TimeLayout) {
+		stamp, project = stamp[:len(TimeLayout)], strings.TrimPrefix(stamp[len(TimeLayout):], " ")
+	}
+	t, err := time.ParseInLocation(TimeLayout, stamp, time.Local)
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Time: t, Query: query, Response: response, Project: project}, true
+}
+
+// MemoryStore keeps history in memory only, for incognito sessions,
+// tests, and embedding. The zero value is ready to use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *MemoryStore) Save(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *MemoryStore) Search(term string, limit int) ([]Entry, error) {
+	return s.SearchProject("", term, limit)
+}
+
+func (s *MemoryStore) SearchProject(project, term string, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return searchEntries(s.entries, project, term, limit), nil
+}
+
+func (s *MemoryStore) Prune(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.entries)
+	s.entries = slices.DeleteFunc(s.entries, func(e Entry) bool { return e.Time.Before(cutoff) })
+	return before - len(s.entries), nil
+}
+
+func (s *MemoryStore) Keep(n int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) <= n {
+		return 0, nil
+	}
+	removed := len(s.entries) - n
+	s.entries = slices.Delete(s.entries, 0, removed)
+	return removed, nil
+}
+
+func (s *MemoryStore) MarkExecuted(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if sameEntry(s.entries[i], entry) {
+			s.entries[i].Executed = true
+			s.entries[i].Environment = entry.Environment
+			s.entries[i].Recording = entry.Recording
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Import(entries []Entry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := 0
+	for _, e := range entries {
+		if !slices.ContainsFunc(s.entries, func(x Entry) bool { return sameEntry(x, e) }) {
+			s.entries = append(s.entries, e)
+			added++
+		}
+	}
+	slices.SortStableFunc(s.entries, func(a, b Entry) int { return a.Time.Compare(b.Time) })
+	return added, nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+// SQLiteStore keeps history in a SQLite database, which stays fast
+// to search and prune as history grows. The driver is pure Go, so builds
+// remain CGO-free.
+type SQLiteStore struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenSQLite opens (creating if needed) the database at path.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	// Create the file ourselves so it is never world-readable
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS history (
+			id       INTEGER PRIMARY KEY AUTOINCREMENT,
+			time     INTEGER NOT NULL, -- Unix nanoseconds
+			query    TEXT NOT NULL,
+			response TEXT NOT NULL,
+			project  TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS history_time ON history(time);`)
+	if err == nil {
+		err = addColumns(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not initialize %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db, path: path}, nil
+}
+
+// addedColumns are the columns added to the history table after its first
+// release, in order.
+var addedColumns = []struct{ name, def string }{
+	{"project", "TEXT NOT NULL DEFAULT ''"},
+	{"command", "TEXT NOT NULL DEFAULT ''"},
+	{"explanation", "TEXT NOT NULL DEFAULT ''"},
+	{"provider", "TEXT NOT NULL DEFAULT ''"},
+	{"model", "TEXT NOT NULL DEFAULT ''"},
+	{"platform", "TEXT NOT NULL DEFAULT ''"},
+	{"executed", "INTEGER NOT NULL DEFAULT 0"},
+	{"environment", "TEXT NOT NULL DEFAULT ''"},
+	{"recording", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// addColumns upgrades databases created before entries carried every
+// field.
+func addColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		var n int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('history') WHERE name = ?`, c.name).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE history ADD COLUMN ` + c.name + ` ` + c.def); err != nil {
+			// Another process opening the database may have just added it
+			if db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('history') WHERE name = ?`, c.name).Scan(&n) == nil && n > 0 {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Path returns the database file's path.
+func (s *SQLiteStore) Path() string { return s.path }
+
+// sqlExecer is satisfied by *sql.DB and *sql.Tx.
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertEntry inserts entry, skipping it when unique is set and an entry
+// with the same time (to the second, see sameEntry) and query exists. It reports whether a row was added.
+func insertEntry(db sqlExecer, entry Entry, unique bool) (bool, error) {
+	res, err := db.Exec(`
+		INSERT INTO history (time, query, response, project, command, explanation, provider, model, platform, executed, environment, recording)
+		SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?12, ?13
+		WHERE NOT ?11 OR NOT EXISTS (SELECT 1 FROM history WHERE time >= ?14 AND time < ?14 + 1000000000 AND query = ?2)`,
+		entry.Time.UnixNano(), entry.Query, entry.Response, entry.Project,
+		entry.Command, entry.Explanation, entry.Provider, entry.Model, entry.Platform, entry.Executed, unique, entry.Environment, entry.Recording,
+		entry.Time.Truncate(time.Second).UnixNano())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteStore) Save(entry Entry) error {
+	_, err := insertEntry(s.db, entry, false)
+	return err
+}
+
+func (s *SQLiteStore) MarkExecuted(entry Entry) error {
+	_, err := s.db.Exec(`UPDATE history SET executed = 1, environment = ?, recording = ? WHERE time = ? AND query = ?`,
+		entry.Environment, entry.Recording, entry.Time.UnixNano(), entry.Query)
+	return err
+}
+
+// Import adds entries in one transaction, so a failed migration leaves
+// the database as it was.
+func (s *SQLiteStore) Import(entries []Entry) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	added := 0
+	for _, e := range entries {
+		ok, err := insertEntry(tx, e, true)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+func (s *SQLiteStore) Search(term string, limit int) ([]Entry, error) {
+	return s.SearchProject("", term, limit)
+}
+
+func (s *SQLiteStore) SearchProject(project, term string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := s.db.Query(`
+		SELECT time, query, response, project, command, explanation, provider, model, platform, executed, environment, recording FROM history
+		WHERE (?1 = '' OR instr(lower(query), ?1) > 0 OR instr(lower(response), ?1) > 0)
+		  AND (?3 = '' OR project = ?3)
+		ORDER BY time DESC, id DESC
+		LIMIT ?2`, strings.ToLower(term), limit, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []Entry
+	for rows.Next() {
+		var nanos int64
+		var e Entry
+		if err := rows.Scan(&nanos, &e.Query, &e.Response, &e.Project,
+			&e.Command, &e.Explanation, &e.Provider, &e.Model, &e.Platform, &e.Executed, &e.Environment, &e.Recording); err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(0, nanos)
+		found = append(found, e)
+	}
+	return found, rows.Err()
+}
+
+func (s *SQLiteStore) Prune(cutoff time.Time) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM history WHERE time < ?`, cutoff.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (s *SQLiteStore) Keep(n int) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM history WHERE id NOT IN (SELECT id FROM history ORDER BY time DESC, id DESC LIMIT ?)`, n)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
+
+// Compact rebuilds the database without the pages deleted entries left
+// behind, so their text can't be recovered from history.db.
+func (s *SQLiteStore) Compact() error {
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
+
+func (s *SQLiteStore) Close() error { return s.db.Close() }
+
+// SplitEntries splits history file contents into individual
+// "[timestamp project] query\nresponse\n---\n" entries.
+func SplitEntries(text string) []string {
+	var entries []string
+	for _, chunk := range strings.SplitAfter(text, "\n---\n") {
+		if strings.TrimSpace(chunk) != "" {
+			entries = append(entries, chunk)
+		}
+	}
+	return entries
+}
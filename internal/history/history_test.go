@@ -0,0 +1,307 @@
+package history
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHistoryStores runs the same save/search/prune contract against every backend.
+func TestHistoryStores(t *testing.T) {
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := Open(backend, dir)
+			if err != nil {
+				t.Fatalf("Open(%q): %v", backend, err)
+			}
+			defer store.Close()
+
+			base := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+			entries := []Entry{
+				{Time: base, Query: "compress a directory", Response: "tar -czf out.tar.gz dir"},
+				{Time: base.Add(24 * time.Hour), Query: "list files", Response: "ls -la\nLists files"},
+				{Time: base.Add(48 * time.Hour), Query: "extract archive", Response: "tar -xzf out.tar.gz"},
+			}
+			for _, e := range entries {
+				if err := store.Save(e); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+			}
+
+			found, err := store.Search("TAR", 0)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(found) != 2 || found[0].Query != "extract archive" || found[1].Query != "compress a directory" {
+				t.Errorf("Search(TAR) = %+v, want both tar entries newest first", found)
+			}
+			if !found[1].Time.Equal(base) {
+				t.Errorf("timestamp round-trip: got %v, want %v", found[1].Time, base)
+			}
+			if all, _ := store.Search("", 1); len(all) != 1 || all[0].Query != "extract archive" {
+				t.Errorf("Search with limit = %+v, want only the newest entry", all)
+			}
+			if got, _ := store.Search("", 0); len(got) != 3 || got[1].Response != "ls -la\nLists files" {
+				t.Errorf("multi-line response not preserved: %+v", got)
+			}
+
+			tagged := Entry{Time: base.Add(50 * time.Hour), Query: "run migrations", Response: "make migrate", Project: "github.com/acme/api"}
+			if err := store.Save(tagged); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if got, _ := store.SearchProject("github.com/acme/api", "", 0); len(got) != 1 || got[0] != tagged {
+				t.Errorf("SearchProject = %+v, want only the tagged entry", got)
+			}
+			if got, _ := store.SearchProject("github.com/acme/web", "", 0); len(got) != 0 {
+				t.Errorf("SearchProject for another project = %+v, want none", got)
+			}
+			if got, _ := store.Search("migrat", 0); len(got) != 1 || got[0].Project != tagged.Project {
+				t.Errorf("Search should include tagged entries: %+v", got)
+			}
+
+			// Import skips entries already stored, comparing times to the
+			// second as the plain-text format keeps them
+			imported := []Entry{entries[1], {Time: base.Add(36 * time.Hour), Query: "show uptime", Response: "uptime"},
+				{Time: base.Add(400 * time.Millisecond), Query: entries[0].Query, Response: entries[0].Response}}
+			if added, err := store.Import(imported); err != nil || added != 1 {
+				t.Errorf("Import = %d, %v; want 1 added", added, err)
+			}
+			if got, _ := store.Search("", 0); len(got) != 5 || got[2].Query != "show uptime" {
+				t.Errorf("entries after Import = %+v, want the new one in time order", got)
+			}
+			if err := store.MarkExecuted(entries[2]); err != nil {
+				t.Errorf("MarkExecuted: %v", err)
+			}
+
+			removed, err := store.Prune(base.Add(time.Hour))
+			if err != nil || removed != 1 {
+				t.Errorf("Prune = %d, %v; want 1 removed", removed, err)
+			}
+			if left, _ := store.Search("", 0); len(left) != 4 {
+				t.Errorf("%d entries left after prune, want 4", len(left))
+			}
+
+			if removed, err := store.Keep(2); err != nil || removed != 2 {
+				t.Errorf("Keep = %d, %v; want 2 removed", removed, err)
+			}
+			if left, _ := store.Search("", 0); len(left) != 2 || left[0].Query != "run migrations" || left[1].Query != "extract archive" {
+				t.Errorf("entries after Keep = %+v, want the newest two", left)
+			}
+
+			// Deleted text is gone from the file once compacted
+			if c, ok := store.(Compacter); ok {
+				if err := c.Compact(); err != nil {
+					t.Errorf("Compact: %v", err)
+				}
+				files, _ := filepath.Glob(filepath.Join(dir, "*.db"))
+				for _, name := range files {
+					if data, _ := os.ReadFile(name); bytes.Contains(data, []byte("show uptime")) {
+						t.Errorf("%s still holds a deleted entry after Compact", name)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := Open("redis", t.TempDir()); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+// TestFileStoreImport verifies that importing, as sync does, merges into
+// the history file without duplicates and in timestamp order.
+func TestFileStoreImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	local := "[2026-01-01 10:00:00] list files\nls -la\n---\n" +
+		"[2026-01-03 10:00:00] disk usage\ndu -sh\n---\n"
+	remote := "[2026-01-02 10:00:00] find go files\nfind . -name '*.go'\n---\n" +
+		"[2026-01-01 10:00:00] list files\nls -la\n---\n"
+	if err := os.WriteFile(path, []byte(local), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := NewFileStore(path).Import(ParseEntries(remote)); err != nil || added != 1 {
+		t.Fatalf("Import = %d, %v; want 1 added", added, err)
+	}
+	got, _ := os.ReadFile(path)
+	want := "[2026-01-01 10:00:00] list files\nls -la\n---\n" +
+		"[2026-01-02 10:00:00] find go files\nfind . -name '*.go'\n---\n" +
+		"[2026-01-03 10:00:00] disk usage\ndu -sh\n---\n"
+	if string(got) != want {
+		t.Errorf("merged file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestFileStoreConcurrentWrites verifies that entries saved while another
+// process (here, another file handle) rewrites the file aren't lost.
+func TestFileStoreConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			if err := NewFileStore(path).Save(Entry{Time: base.Add(time.Duration(i) * time.Hour), Query: fmt.Sprintf("saved %d", i), Response: "ls"}); err != nil {
+				t.Error(err)
+			}
+		})
+		wg.Go(func() {
+			if _, err := NewFileStore(path).Import([]Entry{{Time: base.Add(-time.Duration(i+1) * time.Hour), Query: fmt.Sprintf("imported %d", i), Response: "ls"}}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if got, _ := NewFileStore(path).Search("", 0); len(got) != 40 {
+		t.Errorf("file has %d entries after concurrent writes, want 40", len(got))
+	}
+}
+
+// TestStructuredEntries verifies the backends other than the plain-text
+// file keep an answer's details and execution.
+func TestStructuredEntries(t *testing.T) {
+	for _, backend := range []string{BackendSQLite, BackendMemory} {
+		t.Run(backend, func(t *testing.T) {
+			store, err := Open(backend, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			entry := Entry{
+				Time: time.Now(), Query: "list files", Response: "ls -la\nLists all files",
+				Command: "ls -la", Explanation: "Lists all files", Provider: "anthropic", Model: "claude-haiku-4-5", Platform: "linux",
+			}
+			if err := store.Save(entry); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.MarkExecuted(entry); err != nil {
+				t.Fatal(err)
+			}
+			entry.Executed = true
+			entry.Environment = "os: linux/amd64; shell: sh (dash); dir: ~/src; tools: ls 9.4"
+			entry.Recording = "/home/me/.local/state/howtfdoi/recordings/20260314-090000-list-files.cast"
+			if err := store.MarkExecuted(entry); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := store.Search("", 0)
+			if len(got) != 1 || !got[0].Time.Equal(entry.Time) {
+				t.Fatalf("Search = %+v", got)
+			}
+			got[0].Time = entry.Time // SQLite drops the monotonic clock reading
+			if got[0] != entry {
+				t.Errorf("round trip = %+v, want %+v", got[0], entry)
+			}
+		})
+	}
+}
+
+// TestMigrateFile verifies the default backend imports the plain-text
+// history file once and sets it aside.
+func TestMigrateFile(t *testing.T) {
+	dir := t.TempDir()
+	log := "[2026-01-01 10:00:00] list files\nls -la\n---\n" +
+		"[2026-01-02 10:00:00 github.com/acme/api] run migrations\nmake migrate\n---\n"
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open("", dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, _ := store.Search("", 0)
+	store.Close()
+	if len(got) != 2 || got[0].Query != "run migrations" || got[0].Project != "github.com/acme/api" || got[1].Response != "ls -la" {
+		t.Errorf("migrated entries = %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName)); !os.IsNotExist(err) {
+		t.Error("history file was not set aside after migration")
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName+MigratedSuffix)); err != nil {
+		t.Errorf("migrated file missing: %v", err)
+	}
+
+	// Entries already migrated aren't imported twice
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err = Open(BackendSQLite, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if got, _ := store.Search("", 0); len(got) != 2 {
+		t.Errorf("%d entries after a second migration, want 2", len(got))
+	}
+}
+
+// TestOpenSQLiteAddsProjectColumn verifies databases created before entries
+// carried a project are upgraded in place.
+func TestOpenSQLiteAddsProjectColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DBFileName)
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE history (id INTEGER PRIMARY KEY AUTOINCREMENT, time INTEGER NOT NULL, query TEXT NOT NULL, response TEXT NOT NULL);
+		INSERT INTO history (time, query, response) VALUES (1, 'old query', 'ls')`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite on an old database: %v", err)
+	}
+	defer store.Close()
+	if err := store.Save(Entry{Time: time.Now(), Query: "new query", Response: "pwd", Project: "github.com/acme/api"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got, _ := store.Search("", 0); len(got) != 2 || got[0].Project != "github.com/acme/api" || got[1].Project != "" {
+		t.Errorf("entries after upgrade = %+v", got)
+	}
+}
+
+// TestRetention verifies that each limit removes the oldest entries.
+func TestRetention(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	fill := func() *MemoryStore {
+		store := &MemoryStore{}
+		for i := 5; i > 0; i-- {
+			store.Save(Entry{Time: now.Add(-time.Duration(i) * 24 * time.Hour), Query: "query", Response: "answer"})
+		}
+		return store
+	}
+	entrySize := int64(len(formatEntry(Entry{Time: now, Query: "query", Response: "answer"})))
+
+	tests := []struct {
+		name string
+		r    Retention
+		left int
+	}{
+		{"no limits", Retention{}, 5},
+		{"max entries", Retention{MaxEntries: 3}, 3},
+		{"max age", Retention{MaxAge: 60 * time.Hour}, 2},
+		{"max bytes", Retention{MaxBytes: 4*entrySize + 1}, 4},
+		{"tightest limit wins", Retention{MaxEntries: 4, MaxBytes: 2 * entrySize}, 2},
+		{"nothing fits", Retention{MaxBytes: 1}, 0},
+	}
+	for _, tt := range tests {
+		store := fill()
+		removed, err := tt.r.Apply(store, now)
+		left, _ := store.Search("", 0)
+		if err != nil || removed != 5-tt.left || len(left) != tt.left {
+			t.Errorf("%s: Apply = %d, %v with %d left; want %d left", tt.name, removed, err, len(left), tt.left)
+		}
+		if tt.left > 0 && !left[0].Time.Equal(now.Add(-24*time.Hour)) {
+			t.Errorf("%s: newest entry was removed", tt.name)
+		}
+	}
+	if (Retention{}).Enabled() || !(Retention{MaxAge: time.Hour}).Enabled() {
+		t.Error("Enabled should report whether any limit is set")
+	}
+}
@@ -0,0 +1,318 @@
+// Package provider sends prompts to the AI backends howtfdoi supports:
+// Anthropic (directly or through Amazon Bedrock), OpenAI and compatible
+// endpoints, Azure OpenAI, LM Studio, and Ollama.
+package provider
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// Default models
+	ClaudeModel = anthropic.ModelClaudeHaiku4_5
+	GPTModel    = "gpt-4o-mini"
+
+	// DefaultMaxTokens is the output budget of each request unless
+	// SetMaxTokens overrides it
+	DefaultMaxTokens = 1024
+
+	// Output budget for OpenAI reasoning models (o1, o3, o4, gpt-5), which
+	// spend part of it thinking before they answer
+	ReasoningMaxTokens = 8192
+
+	// Bedrock defaults: the global cross-region inference profile for the
+	// same model the Anthropic API uses
+	DefaultBedrockModel = "global.anthropic.claude-haiku-4-5-20251001-v1:0"
+
+	// Azure OpenAI defaults: the latest GA data-plane API version
+	DefaultAzureAPIVersion = "2024-10-21"
+)
+
+// Provider defines the interface for AI providers (Anthropic, OpenAI, etc.)
+type Provider interface {
+	// Query sends a query to the AI provider and returns the response text
+	Query(ctx context.Context, systemPrompt, userQuery string) (string, error)
+}
+
+// StreamingProvider is implemented by providers that can report response
+// text as it arrives. onChunk is called for each non-empty delta.
+type StreamingProvider interface {
+	Provider
+	QueryStream(ctx context.Context, systemPrompt, userQuery string, onChunk func(string)) (string, error)
+}
+
+// Anthropic implements Provider for Anthropic's Claude API
+type Anthropic struct {
+	client    anthropic.Client
+	model     anthropic.Model
+	maxTokens int // 0 = DefaultMaxTokens
+}
+
+// NewAnthropic creates a new Anthropic provider
+func NewAnthropic(apiKey string) *Anthropic {
+	return &Anthropic{
+		client: anthropic.NewClient(option.WithAPIKey(apiKey)),
+		model:  ClaudeModel,
+	}
+}
+
+// NewBedrock creates a provider that invokes Claude through Amazon
+// Bedrock. Credentials come from the standard AWS chain (environment,
+// shared config and SSO profiles, instance or task roles); region overrides
+// the chain's region when set.
+func NewBedrock(ctx context.Context, region, model string) (*Anthropic, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region set for Bedrock (set AWS_REGION or bedrock_region in the config file)")
+	}
+	return &Anthropic{
+		client: anthropic.NewClient(bedrock.WithConfig(cfg)),
+		model:  anthropic.Model(cmp.Or(model, DefaultBedrockModel)),
+	}, nil
+}
+
+// Query sends a query to Anthropic's API
+func (p *Anthropic) Query(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return p.QueryStream(ctx, systemPrompt, userQuery, nil)
+}
+
+// QueryStream sends a query to Anthropic's API, passing text deltas to onChunk.
+func (p *Anthropic) QueryStream(ctx context.Context, systemPrompt, userQuery string, onChunk func(string)) (string, error) {
+	stream := p.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
+		Model:     p.model,
+		MaxTokens: int64(cmp.Or(p.maxTokens, DefaultMaxTokens)),
+		System: []anthropic.TextBlockParam{
+			{
+				Type: "text",
+				Text: systemPrompt,
+				// Enable prompt caching for the system prompt
+				CacheControl: anthropic.CacheControlEphemeralParam{
+					Type: "ephemeral",
+				},
+			},
+		},
+		Messages: []anthropic.MessageParam{
+			{
+				Role: "user",
+				Content: []anthropic.ContentBlockParamUnion{
+					anthropic.NewTextBlock(userQuery),
+				},
+			},
+		},
+	})
+
+	var fullResponse strings.Builder
+	for stream.Next() {
+		event := stream.Current()
+		if event.Type == "content_block_delta" {
+			contentDelta := event.AsContentBlockDelta()
+			textDelta := contentDelta.Delta.AsTextDelta()
+			fullResponse.WriteString(textDelta.Text)
+			if onChunk != nil && textDelta.Text != "" {
+				onChunk(textDelta.Text)
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return "", err
+	}
+
+	return fullResponse.String(), nil
+}
+
+// Model returns the model requests are sent to.
+func (p *Anthropic) Model() string { return string(p.model) }
+
+// SetModel changes the model requests are sent to.
+func (p *Anthropic) SetModel(model string) { p.model = anthropic.Model(model) }
+
+// SetMaxTokens overrides the output budget of each request.
+func (p *Anthropic) SetMaxTokens(n int) { p.maxTokens = n }
+
+// OpenAI implements Provider for OpenAI's API and any endpoint
+// that speaks the same protocol (LiteLLM, vLLM, Groq, Together, ...).
+type OpenAI struct {
+	client    *openai.Client
+	model     string
+	baseURL   string // "" for api.openai.com
+	maxTokens int    // 0 = DefaultMaxTokens
+}
+
+// NewOpenAI creates a new OpenAI provider
+func NewOpenAI(apiKey string) *OpenAI {
+	return &OpenAI{
+		client: openai.NewClient(apiKey),
+		model:  GPTModel,
+	}
+}
+
+// NewOpenAICompatible creates a provider for an OpenAI-compatible
+// endpoint at baseURL. apiKey may be empty for servers that don't check it.
+func NewOpenAICompatible(apiKey, baseURL, model string) *OpenAI {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+	return &OpenAI{
+		client:  openai.NewClientWithConfig(config),
+		model:   model,
+		baseURL: baseURL,
+	}
+}
+
+// NewAzureOpenAI creates a provider for an Azure OpenAI deployment.
+// Azure routes requests by deployment name rather than model, so the
+// deployment doubles as the model name reported elsewhere.
+func NewAzureOpenAI(apiKey, endpoint, deployment, apiVersion string) (*OpenAI, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("no Azure OpenAI endpoint set (set AZURE_OPENAI_ENDPOINT or azure_openai_endpoint in the config file)")
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("no Azure OpenAI deployment set (set AZURE_OPENAI_DEPLOYMENT or azure_openai_deployment in the config file)")
+	}
+	config := openai.DefaultAzureConfig(apiKey, endpoint)
+	config.APIVersion = cmp.Or(apiVersion, DefaultAzureAPIVersion)
+	config.AzureModelMapperFunc = func(model string) string { return model }
+	return &OpenAI{
+		client: openai.NewClientWithConfig(config),
+		model:  deployment,
+	}, nil
+}
+
+// ErrEmptyResponse is returned when an endpoint answers without any text.
+var ErrEmptyResponse = errors.New("the endpoint returned an empty response")
+
+// describeError adds a hint to errors that suggest baseURL isn't really an
+// OpenAI-compatible API, e.g. an HTML page or a missing /v1 path. Errors
+// from api.openai.com are returned unchanged.
+func (p *OpenAI) describeError(err error) error {
+	if p.baseURL == "" {
+		return err
+	}
+	var syntaxErr *json.SyntaxError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &syntaxErr), errors.Is(err, openai.ErrTooManyEmptyStreamMessages), errors.Is(err, ErrEmptyResponse),
+		errors.As(err, &reqErr) && (reqErr.HTTPStatusCode == http.StatusNotFound || reqErr.HTTPStatusCode == http.StatusMethodNotAllowed):
+		return fmt.Errorf("%s didn't return an OpenAI-compatible response (the base URL usually ends in /v1): %w", p.baseURL, err)
+	}
+	return err
+}
+
+// IsReasoningModel reports whether model is one of OpenAI's reasoning
+// models, which take different request parameters.
+func IsReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query sends a query to OpenAI's API
+func (p *OpenAI) Query(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return p.QueryStream(ctx, systemPrompt, userQuery, nil)
+}
+
+// QueryStream sends a query to OpenAI's API, passing content deltas to onChunk.
+func (p *OpenAI) QueryStream(ctx context.Context, systemPrompt, userQuery string, onChunk func(string)) (string, error) {
+	request := openai.ChatCompletionRequest{
+		Model:     p.model,
+		MaxTokens: cmp.Or(p.maxTokens, DefaultMaxTokens),
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userQuery,
+			},
+		},
+	}
+	if IsReasoningModel(p.model) {
+		// Reasoning models reject max_tokens in favor of max_completion_tokens
+		request.MaxTokens, request.MaxCompletionTokens = 0, max(ReasoningMaxTokens, p.maxTokens)
+	}
+	stream, err := p.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return "", p.describeError(err)
+	}
+	defer stream.Close()
+
+	var fullResponse strings.Builder
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", p.describeError(err)
+		}
+
+		if len(response.Choices) > 0 {
+			delta := response.Choices[0].Delta.Content
+			fullResponse.WriteString(delta)
+			if onChunk != nil && delta != "" {
+				onChunk(delta)
+			}
+		}
+	}
+	if strings.TrimSpace(fullResponse.String()) == "" {
+		return "", p.describeError(ErrEmptyResponse)
+	}
+
+	return fullResponse.String(), nil
+}
+
+// Model returns the model (or Azure deployment) requests are sent to.
+func (p *OpenAI) Model() string { return p.model }
+
+// SetModel changes the model requests are sent to.
+func (p *OpenAI) SetModel(model string) { p.model = model }
+
+// SetMaxTokens overrides the output budget of each request. Reasoning
+// models never get less than ReasoningMaxTokens.
+func (p *OpenAI) SetMaxTokens(n int) { p.maxTokens = n }
+
+// LMStudio implements Provider for LM Studio's local OpenAI-compatible API.
+// Embeds OpenAI since LM Studio speaks the same protocol.
+type LMStudio struct {
+	*OpenAI
+}
+
+// NewLMStudio creates a new LM Studio provider with a custom base URL.
+func NewLMStudio(baseURL, model string) *LMStudio {
+	return &LMStudio{OpenAI: NewOpenAICompatible("", baseURL, model)}
+}
+
+// Ollama implements Provider for Ollama's local OpenAI-compatible API.
+// Embeds OpenAI since Ollama speaks the same protocol.
+type Ollama struct {
+	*OpenAI
+}
+
+// NewOllama creates a new Ollama provider with a custom base URL.
+func NewOllama(baseURL, model string) *Ollama {
+	return &Ollama{OpenAI: NewOpenAICompatible("", baseURL, model)}
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenAICompatibleErrorHint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty/chat/completions" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<html>not here</html>")
+	}))
+	defer srv.Close()
+
+	for _, base := range []string{srv.URL, srv.URL + "/empty"} {
+		_, err := NewOpenAICompatible("", base, "m").Query(context.Background(), "system", "q")
+		if err == nil || !strings.Contains(err.Error(), "didn't return an OpenAI-compatible response") {
+			t.Errorf("base %s: error %v lacks the base URL hint", base, err)
+		}
+	}
+}
+
+func TestNewBedrock(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	if _, err := NewBedrock(context.Background(), "", ""); err == nil || !strings.Contains(err.Error(), "no AWS region") {
+		t.Errorf("expected a missing-region error, got %v", err)
+	}
+	p, err := NewBedrock(context.Background(), "eu-west-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Model() != DefaultBedrockModel {
+		t.Errorf("model = %q, want %q", p.Model(), DefaultBedrockModel)
+	}
+	p.SetModel("custom")
+	if p.Model() != "custom" {
+		t.Errorf("SetModel: model = %q", p.Model())
+	}
+}
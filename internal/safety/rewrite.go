@@ -0,0 +1,277 @@
+package safety
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Rewrite is a safer equivalent of a command, offered alongside it.
+type Rewrite struct {
+	Rule    string // name of the Rewriter that produced it
+	Command string
+	Reason  string // what the rewrite changes, in a few words
+}
+
+// Rewriter turns a risky command into a safer equivalent. Rewrite returns
+// ok=false when the rule doesn't apply to command.
+type Rewriter struct {
+	Name    string
+	Rewrite func(command string) (safer, reason string, ok bool)
+}
+
+// Names of the built-in rewriters.
+const (
+	RewriteTrash       = "trash"
+	RewriteChmod       = "chmod"
+	RewritePipeToShell = "pipe-to-shell"
+)
+
+// RewriteNames lists the built-in rewriters, in the order they're tried.
+var RewriteNames = []string{RewriteTrash, RewriteChmod, RewritePipeToShell}
+
+// BuiltinRewriters returns the built-in rewriters, except those named in
+// disabled. trash is the command that moves files to the trash (trash,
+// trash-put, or gio trash); when it's "", rm isn't rewritten.
+func BuiltinRewriters(trash string, disabled []string) []Rewriter {
+	var rewriters []Rewriter
+	if trash != "" && !slices.Contains(disabled, RewriteTrash) {
+		rewriters = append(rewriters, Rewriter{RewriteTrash, func(command string) (string, string, bool) {
+			return rewriteRemove(command, trash)
+		}})
+	}
+	if !slices.Contains(disabled, RewriteChmod) {
+		rewriters = append(rewriters, Rewriter{RewriteChmod, rewriteChmod})
+	}
+	if !slices.Contains(disabled, RewritePipeToShell) {
+		rewriters = append(rewriters, Rewriter{RewritePipeToShell, rewritePipeToShell})
+	}
+	return rewriters
+}
+
+// PatternRewriter rewrites commands that re matches by replacing the match
+// with replace, in which $1 or ${name} expand to re's groups.
+func PatternRewriter(name string, re *regexp.Regexp, replace, reason string) Rewriter {
+	return Rewriter{name, func(command string) (string, string, bool) {
+		if !re.MatchString(command) {
+			return "", "", false
+		}
+		return re.ReplaceAllString(command, replace), reason, true
+	}}
+}
+
+// SaferRewrite returns the first rewrite of command by rewriters. A
+// rewriter whose result is the command unchanged doesn't count.
+func SaferRewrite(command string, rewriters []Rewriter) (Rewrite, bool) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return Rewrite{}, false
+	}
+	for _, r := range rewriters {
+		safer, reason, ok := r.Rewrite(command)
+		if safer = strings.TrimSpace(safer); ok && safer != "" && safer != command {
+			return Rewrite{Rule: r.Name, Command: safer, Reason: reason}, true
+		}
+	}
+	return Rewrite{}, false
+}
+
+// parseSingle parses command as one statement with nothing around it: no
+// background, negation, redirections, or a second statement.
+func parseSingle(command string) (*syntax.Stmt, bool) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return nil, false
+	}
+	stmt := file.Stmts[0]
+	if stmt.Background || stmt.Negated || stmt.Coprocess || len(stmt.Redirs) > 0 {
+		return nil, false
+	}
+	return stmt, true
+}
+
+// source returns the text of node as written in command.
+func source(command string, node syntax.Node) string {
+	return command[node.Pos().Offset():node.End().Offset()]
+}
+
+// rewriteRemove turns a plain rm call into one that moves the same paths
+// to the trash, so they can be restored. Calls behind sudo are left alone:
+// root's trash isn't where anyone looks.
+func rewriteRemove(command, trash string) (string, string, bool) {
+	stmt, ok := parseSingle(command)
+	if !ok {
+		return "", "", false
+	}
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Assigns) > 0 || len(call.Args) < 2 || path.Base(staticWord(call.Args[0])) != "rm" {
+		return "", "", false
+	}
+	var paths []string
+	options := true
+	for _, w := range call.Args[1:] {
+		word := source(command, w)
+		switch {
+		case options && word == "--":
+			options = false
+			paths = append(paths, word)
+		case options && strings.HasPrefix(word, "-") && word != "-":
+			// rm's own options (-r, -f, -i, -v) mean nothing to the trash
+		default:
+			paths = append(paths, word)
+		}
+	}
+	if len(paths) == 0 || len(paths) == 1 && paths[0] == "--" {
+		return "", "", false
+	}
+	return trash + " " + strings.Join(paths, " "), "moves them to the trash instead, so they can be restored", true
+}
+
+// saferModes maps permission modes that open files to every user to ones
+// that keep write access to the owner: recursive calls use X, which only
+// makes directories (and files that already are) executable.
+var saferModes = map[string][2]string{ // mode: {single file, recursive}
+	"777":     {"755", "u=rwX,go=rX"},
+	"0777":    {"755", "u=rwX,go=rX"},
+	"666":     {"644", "u=rw,go=r"},
+	"0666":    {"644", "u=rw,go=r"},
+	"a+rwx":   {"u+rwx,go+rx", "u+rwX,go+rX"},
+	"ugo+rwx": {"u+rwx,go+rx", "u+rwX,go+rX"},
+	"a+w":     {"u+w", "u+w"},
+	"o+w":     {"u+w", "u+w"},
+}
+
+// rewriteChmod replaces world-writable modes in chmod calls (also behind
+// sudo, and anywhere in a pipeline or list) with owner-only write access.
+func rewriteChmod(command string) (string, string, bool) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return "", "", false
+	}
+	type edit struct {
+		start, end int
+		mode       string
+	}
+	var edits []edit
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		for i, w := range call.Args {
+			name := path.Base(staticWord(w))
+			if name != "chmod" {
+				if i == 0 && !policyWrappers[name] {
+					break
+				}
+				continue
+			}
+			// GNU chmod takes -R after the mode too
+			recursive := 0
+			for _, arg := range call.Args[i+1:] {
+				if word := staticWord(arg); word == "--recursive" || strings.HasPrefix(word, "-") && !strings.HasPrefix(word, "--") && strings.Contains(word, "R") {
+					recursive = 1
+				}
+			}
+			for _, arg := range call.Args[i+1:] {
+				word := staticWord(arg)
+				if modes, ok := saferModes[word]; ok {
+					edits = append(edits, edit{int(arg.Pos().Offset()), int(arg.End().Offset()), modes[recursive]})
+				}
+				if !strings.HasPrefix(word, "-") {
+					break // only the first operand is the mode
+				}
+			}
+			break
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return "", "", false
+	}
+	safer := command
+	for _, e := range slices.Backward(edits) {
+		safer = safer[:e.start] + e.mode + safer[e.end:]
+	}
+	return safer, "keeps write access to the owner instead of giving it to every user", true
+}
+
+// rewritePipeToShell turns `curl URL | sh` (or wget, bash, sudo bash)
+// into downloading the script and opening it in a pager, so it's read
+// before it's run. The reason says how to run it afterwards.
+func rewritePipeToShell(command string) (string, string, bool) {
+	stmt, ok := parseSingle(command)
+	if !ok {
+		return "", "", false
+	}
+	pipe, ok := stmt.Cmd.(*syntax.BinaryCmd)
+	if !ok || pipe.Op != syntax.Pipe || len(pipe.X.Redirs) > 0 || len(pipe.Y.Redirs) > 0 {
+		return "", "", false
+	}
+	fetch, ok := pipe.X.Cmd.(*syntax.CallExpr)
+	if !ok || len(fetch.Args) < 2 {
+		return "", "", false
+	}
+	run, ok := pipe.Y.Cmd.(*syntax.CallExpr)
+	if !ok || len(run.Args) == 0 {
+		return "", "", false
+	}
+
+	// The shell, possibly behind sudo, and the arguments given to the
+	// script with -s -- args
+	var prefix []string
+	shellAt := -1
+	for i, w := range run.Args {
+		word := path.Base(staticWord(w))
+		if policyShells[word] {
+			shellAt = i
+			break
+		}
+		if i == 0 && word != "sudo" && word != "doas" {
+			return "", "", false
+		}
+		prefix = append(prefix, source(command, w))
+	}
+	if shellAt < 0 {
+		return "", "", false
+	}
+	prefix = append(prefix, source(command, run.Args[shellAt]))
+	var args []string
+	for i, w := range run.Args[shellAt+1:] {
+		word := staticWord(w)
+		if i == 0 && (word == "-s" || word == "-") || word == "--" && len(args) == 0 {
+			continue
+		}
+		args = append(args, source(command, w))
+	}
+
+	url := ""
+	for _, w := range fetch.Args[1:] {
+		if word := staticWord(w); strings.HasPrefix(word, "https://") || strings.HasPrefix(word, "http://") {
+			url = source(command, w)
+			break
+		}
+	}
+	if url == "" {
+		return "", "", false
+	}
+	script := path.Base(strings.Trim(strings.SplitN(strings.Trim(url, `'"`), "?", 2)[0], "/"))
+	if !strings.HasSuffix(script, ".sh") || strings.ContainsAny(script, "'\"$`\\ ") {
+		script = "install.sh"
+	}
+
+	var download string
+	switch path.Base(staticWord(fetch.Args[0])) {
+	case "curl":
+		download = source(command, fetch) + " -o " + script
+	case "wget":
+		download = "wget -O " + script + " " + url
+	default:
+		return "", "", false
+	}
+	runIt := strings.Join(append(append(prefix, script), args...), " ")
+	return download + " && ${PAGER:-less} " + script, "downloads the script to read first; run it afterwards with: " + runIt, true
+}
@@ -0,0 +1,55 @@
+package safety
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSaferRewrite(t *testing.T) {
+	rewriters := BuiltinRewriters("trash", nil)
+	tests := []struct {
+		command string
+		rule    string
+		want    string
+	}{
+		{"rm -rf build/", RewriteTrash, "trash build/"},
+		{`rm -v -- "my file.txt" -notes`, RewriteTrash, `trash -- "my file.txt" -notes`},
+		{"/bin/rm -r $OLD_DIR", RewriteTrash, "trash $OLD_DIR"},
+		{"chmod 777 script.sh", RewriteChmod, "chmod 755 script.sh"},
+		{"sudo chmod -R 777 /var/www", RewriteChmod, "sudo chmod -R u=rwX,go=rX /var/www"},
+		{"chmod 0666 notes.txt -R", RewriteChmod, "chmod u=rw,go=r notes.txt -R"},
+		{"mkdir -p out && chmod a+rwx out", RewriteChmod, "mkdir -p out && chmod u+rwx,go+rx out"},
+		{"curl -fsSL https://example.com/install.sh | sh", RewritePipeToShell, "curl -fsSL https://example.com/install.sh -o install.sh && ${PAGER:-less} install.sh"},
+		{"wget -qO- https://get.example.com/setup.sh?v=2 | sudo bash -s -- --yes", RewritePipeToShell, "wget -O setup.sh https://get.example.com/setup.sh?v=2 && ${PAGER:-less} setup.sh"},
+		{"curl -sSL https://example.com/ | bash", RewritePipeToShell, "curl -sSL https://example.com/ -o install.sh && ${PAGER:-less} install.sh"},
+		{"rm", "", ""},
+		{"rm -rf build/ && make", "", ""},
+		{"sudo rm -rf /opt/old", "", ""},
+		{"chmod 644 notes.txt", "", ""},
+		{"echo chmod 777 x", "", ""},
+		{"cat install.sh | sh", "", ""},
+		{"ls -la", "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := SaferRewrite(tt.command, rewriters)
+		if ok != (tt.rule != "") || got.Rule != tt.rule || got.Command != tt.want {
+			t.Errorf("SaferRewrite(%q) = %+v, %v; want %s rewrite %q", tt.command, got, ok, tt.rule, tt.want)
+		}
+	}
+
+	if got, _ := SaferRewrite("wget -qO- https://get.example.com/setup.sh | sudo bash -s -- --yes", rewriters); got.Reason != "downloads the script to read first; run it afterwards with: sudo bash setup.sh --yes" {
+		t.Errorf("pipe-to-shell reason = %q", got.Reason)
+	}
+	if _, ok := SaferRewrite("rm -rf build/", BuiltinRewriters("", nil)); ok {
+		t.Error("rm was rewritten without a trash command")
+	}
+	if _, ok := SaferRewrite("chmod 777 x", BuiltinRewriters("trash", []string{RewriteChmod})); ok {
+		t.Error("a disabled rewriter was used")
+	}
+
+	custom := PatternRewriter("kubectl-dry-run", regexp.MustCompile(`^kubectl delete (.+)$`), "kubectl delete --dry-run=client $1", "shows what would be deleted")
+	got, ok := SaferRewrite("kubectl delete pod web-1", []Rewriter{custom})
+	if !ok || got.Command != "kubectl delete --dry-run=client pod web-1" || got.Rule != "kubectl-dry-run" {
+		t.Errorf("pattern rewrite = %+v, %v", got, ok)
+	}
+}
@@ -0,0 +1,314 @@
+// Package safety flags shell commands that deserve a second look: those
+// matching dangerous patterns, and those a blocklist forbids running. It
+// also rewrites risky commands into safer equivalents.
+package safety
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Severity ranks the harm a dangerous command could do.
+type Severity int
+
+const (
+	SeverityNone     Severity = iota
+	SeverityHigh              // loses a file or runs unreviewed code: mv to /dev/null, curl | sh
+	SeverityCritical          // wipes a disk, a home directory, or the system: rm -rf /, mkfs
+)
+
+// Severities lists the levels of dangerous commands by name, lowest first.
+var Severities = []string{"high", "critical"}
+
+// String returns the severity's name, as listed in Severities.
+func (s Severity) String() string {
+	switch s {
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "none"
+}
+
+// ParseSeverity returns the severity named name (one of Severities).
+func ParseSeverity(name string) (Severity, bool) {
+	i := slices.Index(Severities, strings.ToLower(name))
+	return Severity(i + 1), i >= 0
+}
+
+// dangerousPattern is a built-in pattern and the severity of a match.
+type dangerousPattern struct {
+	re       *regexp.Regexp
+	severity Severity
+}
+
+// dangerousPatterns are the built-in dangerous command patterns. They are a
+// best-effort warning, not a security boundary: the confirmation prompt
+// before running a command is the real gate.
+var dangerousPatterns = []dangerousPattern{
+	// rm with combined recursive+force flags (either order, extra flags
+	// allowed) targeting root, a wildcard, or the bare home directory
+	{regexp.MustCompile(`rm\s+-(rf|fr)\w*\s+(/|\*|~(\s|$))`), SeverityCritical},
+	{regexp.MustCompile(`dd\s+.*of=/dev/`), SeverityCritical},
+	{regexp.MustCompile(`mkfs\.`), SeverityCritical},
+	// Fork bomb, tolerant of whitespace variants
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`), SeverityCritical},
+	{regexp.MustCompile(`>\s*/dev/sd`), SeverityCritical},
+	{regexp.MustCompile(`mv\s+.*\s+/dev/null`), SeverityHigh},
+	// Piping anything into a shell (curl | sh installers etc.)
+	{regexp.MustCompile(`\|\s*(sudo\s+)?(ba|z|fi)?sh(\s|$)`), SeverityHigh},
+	// World-writable root
+	{regexp.MustCompile(`chmod\s+(-\w+\s+)*777\s+/(\s|$)`), SeverityCritical},
+}
+
+// IsDangerous reports whether command matches a built-in dangerous pattern
+// or one of extra.
+func IsDangerous(command string, extra ...*regexp.Regexp) bool {
+	return Classify(command, extra...) != SeverityNone
+}
+
+// Classify returns the highest severity of the built-in patterns command
+// matches. A match of one of extra (user-supplied patterns) is high.
+func Classify(command string, extra ...*regexp.Regexp) Severity {
+	severity := SeverityNone
+	for _, p := range dangerousPatterns {
+		if p.severity > severity && p.re.MatchString(command) {
+			severity = p.severity
+		}
+	}
+	if severity == SeverityNone && slices.ContainsFunc(extra, func(re *regexp.Regexp) bool { return re.MatchString(command) }) {
+		severity = SeverityHigh
+	}
+	return severity
+}
+
+// policyWrappers run a later word as the real program, possibly after their
+// own options (sudo -u root, timeout 5, env FOO=1). Every word after one of
+// them is treated as a possible program.
+var policyWrappers = map[string]bool{
+	"sudo": true, "doas": true, "time": true, "nice": true, "ionice": true,
+	"nohup": true, "command": true, "exec": true, "env": true, "xargs": true,
+	"timeout": true, "watch": true, "strace": true, "chroot": true,
+	"busybox": true, "stdbuf": true, "unbuffer": true, "setsid": true,
+	"taskset": true, "chrt": true,
+}
+
+// policyShells take a script with -c, which is checked like a command line.
+var policyShells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true}
+
+// BlockedRule returns the first blocklist rule that command violates, or ""
+// if it may run. A rule is a program name optionally followed by arguments:
+// "dd" matches any dd call, "kubectl delete" a kubectl call with delete
+// among its arguments (so kubectl -n prod delete pod x is caught too).
+// Every call in the command line is checked, including pipelines,
+// subshells, command substitutions, sh -c scripts, and eval arguments.
+// Programs are compared by base name, so /bin/dd is dd, and with escapes
+// removed, so \dd is too. A program that is only known at run time
+// ($(echo dd), "$X") matches every rule, since it could be anything.
+//
+// This is a guard against mistakes, not a sandbox: a command can still
+// reach a blocked program indirectly, e.g. through a script it writes
+// and then runs.
+func BlockedRule(blocklist []string, command string) string {
+	if len(blocklist) == 0 || strings.TrimSpace(command) == "" {
+		return ""
+	}
+	for _, call := range policyCalls(command, 0) {
+		for _, rule := range blocklist {
+			if callMatchesRule(call, strings.Fields(rule)) {
+				return rule
+			}
+		}
+	}
+	return ""
+}
+
+// BlockedRuleAnyWord is BlockedRule for commands run by a shell whose
+// syntax isn't sh's, such as fish, nushell, cmd, or PowerShell, where
+// command can't be parsed into calls. Any word can be the program there,
+// as in fish's (echo dd), so a rule matches when its program is any word
+// of command and its arguments follow in order. Words are split at shell
+// punctuation and path separators, escapes (\, `, ^) are dropped, and
+// case and a .exe suffix are ignored.
+func BlockedRuleAnyWord(blocklist []string, command string) string {
+	if len(blocklist) == 0 || strings.TrimSpace(command) == "" {
+		return ""
+	}
+	var words []string
+	add := func(word string) {
+		if word = strings.TrimSuffix(strings.ToLower(word), ".exe"); word != "" {
+			words = append(words, word)
+		}
+	}
+	for _, field := range strings.FieldsFunc(command, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("()[]{};|&<>$'\"", r)
+	}) {
+		add(strings.NewReplacer(`\`, "", "`", "", "^", "").Replace(field))
+		if strings.ContainsAny(field, `/\`) {
+			for _, part := range strings.FieldsFunc(field, func(r rune) bool { return r == '/' || r == '\\' }) {
+				add(part)
+			}
+		}
+	}
+	for _, rule := range blocklist {
+		fields := strings.Fields(strings.ToLower(rule))
+		for i, word := range words {
+			if len(fields) > 0 && word == fields[0] && containsInOrder(words[i+1:], fields[1:]) {
+				return rule
+			}
+		}
+	}
+	return ""
+}
+
+// policyCalls returns the words of every simple command in command. Words
+// that aren't static text are kept as "". Unparseable input is checked as
+// one call of whitespace-separated words, erring towards blocking.
+// Scripts given to sh -c or eval are parsed as well; one that isn't
+// static text is kept as a call whose program is "".
+func policyCalls(command string, depth int) [][]string {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return [][]string{strings.Fields(command)}
+	}
+
+	var calls [][]string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		words := make([]string, 0, len(call.Args))
+		for _, w := range call.Args {
+			words = append(words, staticWord(w))
+		}
+		calls = append(calls, words)
+
+		// Look inside sh -c '...' and eval '...', also behind wrappers
+		// like sudo (bounded, in case of sh -c "sh -c ...")
+		if script, ok := shellScript(words); ok {
+			switch {
+			case script == "" || depth >= 4:
+				calls = append(calls, []string{""})
+			default:
+				calls = append(calls, policyCalls(script, depth+1)...)
+			}
+		}
+		return true
+	})
+	return calls
+}
+
+// shellScript returns the script a call passes to a shell with -c, or to
+// eval. The script is "" when any of it is only known at run time.
+func shellScript(words []string) (string, bool) {
+	for i, word := range words {
+		if filepath.Base(word) == "eval" {
+			if slices.Contains(words[i+1:], "") {
+				return "", true
+			}
+			return strings.Join(words[i+1:], " "), len(words) > i+1
+		}
+		if policyShells[filepath.Base(word)] {
+			for j := i + 1; j < len(words)-1; j++ {
+				if w := words[j]; strings.HasPrefix(w, "-") && !strings.HasPrefix(w, "--") && strings.Contains(w, "c") {
+					return words[j+1], true
+				}
+			}
+			return "", false
+		}
+		if i == 0 && !policyWrappers[filepath.Base(word)] {
+			break
+		}
+	}
+	return "", false
+}
+
+// staticWord returns the text of w with quotes and escapes removed, as the
+// shell would pass it, or "" when any part of it is expanded at run time.
+func staticWord(w *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range w.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(unescape(part.Value, false))
+		case *syntax.SglQuoted:
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return ""
+				}
+				b.WriteString(unescape(lit.Value, true))
+			}
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// unescape removes the backslashes the shell would from a literal. Inside
+// double quotes, only \$, \`, \", \\, and line continuations are escapes.
+func unescape(s string, quoted bool) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == '\n':
+			i++ // a line continuation disappears
+		case !quoted || strings.IndexByte("$`\"\\", next) >= 0:
+			b.WriteByte(next)
+			i++
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// callMatchesRule reports whether call runs rule[0] with rule[1:] appearing,
+// in order, among the arguments that follow it. A program word that's only
+// known at run time ("") matches any rule.
+func callMatchesRule(call, rule []string) bool {
+	if len(rule) == 0 {
+		return false
+	}
+	for i, word := range call {
+		if word == "" || filepath.Base(word) == rule[0] && containsInOrder(call[i+1:], rule[1:]) {
+			return true
+		}
+		// Only the first word is the program, unless it's a wrapper
+		if i == 0 && !policyWrappers[filepath.Base(word)] {
+			return false
+		}
+	}
+	return false
+}
+
+// containsInOrder reports whether want is a subsequence of words.
+func containsInOrder(words, want []string) bool {
+	for _, w := range words {
+		if len(want) == 0 {
+			break
+		}
+		if w == want[0] {
+			want = want[1:]
+		}
+	}
+	return len(want) == 0
+}
@@ -0,0 +1,182 @@
+package safety
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestIsDangerous(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    bool
+	}{
+		{"rm root", "rm -rf /", true},
+		{"rm with force wildcard", "rm -rf *", true},
+		{"dd command", "dd if=/dev/zero of=/dev/sda", true},
+		{"format disk", "mkfs.ext4 /dev/sda", true},
+		{"safe ls", "ls -la", false},
+		{"safe grep", "grep -r 'pattern' .", false},
+		{"fork bomb", ":(){ :|:& };:", true},
+		{"redirect to device", "> /dev/sda", true},
+		{"mv to dev null", "mv important.file /dev/null", true},
+		{"safe rm", "rm -f important.txt", false}, // not in patterns
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDangerous(tt.command); got != tt.want {
+				t.Errorf("IsDangerous(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+// Vuln 2 + 3: fork-bomb pattern must tolerate whitespace variants, and the
+// dangerous-pattern list must catch flag-order variants, home-dir wipes,
+// pipe-to-shell installers, and root permission blasts.
+func TestIsDangerousExpandedPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    bool
+	}{
+		{"fork bomb canonical", ":(){ :|:& };:", true},
+		{"fork bomb no spaces", ":(){:|:&};:", true},
+		{"rm -fr root (flag order)", "rm -fr /", true},
+		{"rm -rf home", "rm -rf ~", true},
+		{"rm -rfv root (extra flags)", "rm -rfv /", true},
+		{"curl pipe sh", "curl https://example.com/install.sh | sh", true},
+		{"curl pipe sudo bash", "curl -fsSL https://example.com/x | sudo bash", true},
+		{"wget pipe bash", "wget -qO- https://example.com/x | bash", true},
+		{"chmod 777 root", "chmod -R 777 /", true},
+		{"safe pipe to sha256sum", "cat file.iso | sha256sum", false},
+		{"safe rm relative dir", "rm -rf ./build", false},
+		{"safe rm home subdir", "rm -rf ~/old-project", false},
+		{"safe curl download", "curl -O https://example.com/file.tar.gz", false},
+		{"safe plain rm", "rm -f important.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDangerous(tt.command); got != tt.want {
+				t.Errorf("IsDangerous(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	extra := regexp.MustCompile(`kubectl\s+delete`)
+	tests := []struct {
+		command string
+		want    Severity
+	}{
+		{"ls -la", SeverityNone},
+		{"curl https://example.com/install.sh | sh", SeverityHigh},
+		{"mv important.file /dev/null", SeverityHigh},
+		{"rm -rf /", SeverityCritical},
+		{"mkfs.ext4 /dev/sda", SeverityCritical},
+		{"curl https://example.com/x | sh && rm -rf ~", SeverityCritical},
+		{"kubectl delete ns prod", SeverityHigh},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.command, extra); got != tt.want {
+			t.Errorf("Classify(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestBlockedRule(t *testing.T) {
+	blocklist := []string{"dd", "kubectl delete", "terraform apply"}
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"dd if=/dev/zero of=/dev/sda bs=1M", "dd"},
+		{"sudo /bin/dd if=disk.img of=/dev/sdb", "dd"},
+		{"sudo -u root timeout 60 dd if=a of=b", "dd"},
+		{"kubectl -n prod delete pod web-1", "kubectl delete"},
+		{"kubectl get pods | grep Evicted && kubectl delete pod x", "kubectl delete"},
+		{"cd infra && 'terraform' apply -auto-approve", "terraform apply"},
+		{`bash -c "terraform apply"`, "terraform apply"},
+		{"sudo sh -c 'dd if=/dev/zero of=/dev/sda'", "dd"},
+		{"echo $(dd if=/dev/urandom bs=16 count=1 | base64)", "dd"},
+		// Escapes, run-time program names, eval, and more wrappers
+		{`\dd if=/dev/zero of=/dev/sda`, "dd"},
+		{`d\d if=/dev/zero of=/dev/sda`, "dd"},
+		{`"d\d" if=/dev/zero of=/dev/sda`, ""}, // runs d\d, not dd
+		{"$(echo dd) if=/dev/zero of=/dev/sda", "dd"},
+		{`"$X" if=/dev/zero of=/dev/sda`, "dd"},
+		{"sudo `echo dd` if=a of=b", "dd"},
+		{"eval 'dd if=/dev/zero of=/dev/sda'", "dd"},
+		{"eval kubectl -n prod delete pod x", "kubectl delete"},
+		{`eval "$CMD"`, "dd"},
+		{`sh -c "$CMD"`, "dd"},
+		{"busybox dd if=/dev/zero of=/dev/sda", "dd"},
+		{"stdbuf -oL dd if=a of=b", "dd"},
+		{"unbuffer dd if=a of=b", "dd"},
+		{`echo "$HOME" \dd`, ""},
+		{"eval echo dd", ""},
+		{"kubectl get pods", ""},
+		{"terraform plan", ""},
+		{"man dd", ""},
+		{"echo kubectl delete", ""},
+		{"ddrescue /dev/sda disk.img", ""},
+		{"sh", ""},
+	}
+	for _, tt := range tests {
+		if got := BlockedRule(blocklist, tt.command); got != tt.want {
+			t.Errorf("BlockedRule(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+	if got := BlockedRule(nil, "dd if=a of=b"); got != "" {
+		t.Errorf("an empty blocklist blocked %q", got)
+	}
+}
+
+func TestBlockedRuleAnyWord(t *testing.T) {
+	blocklist := []string{"dd", "kubectl delete", "Remove-Item"}
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"(echo dd) if=/dev/zero of=/dev/sda", "dd"}, // fish
+		{"echo hi; and dd if=a of=b", "dd"},
+		{`^run-external 'dd' if=a of=b`, "dd"}, // nushell
+		{`C:\tools\DD.EXE if=a of=b`, "dd"},
+		{"d`d if=a of=b", "dd"}, // PowerShell escape
+		{`\dd if=a of=b`, "dd"},
+		{"kubectl -n prod delete pod web-1", "kubectl delete"},
+		{"Get-ChildItem build | remove-item -Recurse", "Remove-Item"},
+		{"kubectl get pods", ""},
+		{"ddrescue /dev/sda disk.img", ""},
+		{"echo delete kubectl", ""},
+	}
+	for _, tt := range tests {
+		if got := BlockedRuleAnyWord(blocklist, tt.command); got != tt.want {
+			t.Errorf("BlockedRuleAnyWord(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+	if got := BlockedRuleAnyWord(nil, "dd if=a of=b"); got != "" {
+		t.Errorf("an empty blocklist blocked %q", got)
+	}
+}
+
+// Benchmark dangerous command checking
+func BenchmarkIsDangerous(b *testing.B) {
+	commands := []string{
+		"ls -la",
+		"rm -rf /",
+		"grep -r 'pattern' .",
+		"dd if=/dev/zero of=/dev/sda",
+		"find . -type f",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, cmd := range commands {
+			IsDangerous(cmd)
+		}
+	}
+}